@@ -0,0 +1,112 @@
+package env
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ArchiveFS reads the zip or tar archive at path and returns a lookup where the name of each file of the archive is a variable
+// whose value is the contents of the file, like FileSystem over a bundle of secret files.
+// The format is chosen by extension: .zip, .tar, or .tar.gz and .tgz for gzip compressed tar archives.
+func ArchiveFS(path string) (LookupFunc, error) {
+	var (
+		vars map[string]string
+		err  error
+	)
+
+	switch {
+	case strings.HasSuffix(path, ".zip"):
+		vars, err = readZip(path)
+	case strings.HasSuffix(path, ".tar.gz"), strings.HasSuffix(path, ".tgz"):
+		vars, err = readTar(path, true)
+	case strings.HasSuffix(path, ".tar"):
+		vars, err = readTar(path, false)
+	default:
+		return nil, fmt.Errorf("%s: unsupported archive format: expected .zip, .tar, .tar.gz or .tgz", path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return mapLookup(vars), nil
+}
+
+func readZip(path string) (map[string]string, error) {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return nil, err
+	}
+	defer archive.Close()
+
+	vars := map[string]string{}
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		data, err := readZipFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", file.Name, err)
+		}
+		vars[file.Name] = data
+	}
+
+	return vars, nil
+}
+
+func readZipFile(file *zip.File) (string, error) {
+	r, err := file.Open()
+	if err != nil {
+		return "", err
+	}
+	defer r.Close()
+
+	data, err := io.ReadAll(r)
+	return string(data), err
+}
+
+func readTar(path string, compressed bool) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if compressed {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var (
+		vars    = map[string]string{}
+		archive = tar.NewReader(r)
+	)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return vars, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", header.Name, err)
+		}
+		vars[header.Name] = string(data)
+	}
+}
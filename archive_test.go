@@ -0,0 +1,94 @@
+package env_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+var archiveFiles = []struct{ Name, Body string }{
+	{Name: "DATABASE_PASSWORD", Body: "hunter2"},
+	{Name: "tls/cert.pem", Body: "-----BEGIN CERTIFICATE-----"},
+}
+
+func TestArchiveFSZip(t *testing.T) {
+	var buf bytes.Buffer
+
+	archive := zip.NewWriter(&buf)
+	_, err := archive.Create("tls/")
+	require.NoError(t, err)
+	for _, file := range archiveFiles {
+		w, err := archive.Create(file.Name)
+		require.NoError(t, err)
+		_, err = w.Write([]byte(file.Body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+
+	path := filepath.Join(t.TempDir(), "secrets.zip")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	testArchiveFS(t, path)
+}
+
+func TestArchiveFSTarGz(t *testing.T) {
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	archive := tar.NewWriter(gz)
+	require.NoError(t, archive.WriteHeader(&tar.Header{Name: "tls/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	for _, file := range archiveFiles {
+		require.NoError(t, archive.WriteHeader(&tar.Header{Name: file.Name, Typeflag: tar.TypeReg, Mode: 0o600, Size: int64(len(file.Body))}))
+		_, err := archive.Write([]byte(file.Body))
+		require.NoError(t, err)
+	}
+	require.NoError(t, archive.Close())
+	require.NoError(t, gz.Close())
+
+	path := filepath.Join(t.TempDir(), "secrets.tar.gz")
+	require.NoError(t, os.WriteFile(path, buf.Bytes(), 0o600))
+
+	testArchiveFS(t, path)
+}
+
+func testArchiveFS(t *testing.T, path string) {
+	lookup, err := env.ArchiveFS(path)
+	require.NoError(t, err)
+
+	environment := env.MakeEnvSet(lookup)
+
+	var (
+		password string
+		cert     []byte
+	)
+
+	env.FlagVar(environment, &password, "DATABASE_PASSWORD")
+	env.FlagVar(environment, &cert, "tls/cert.pem")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "hunter2", password)
+	require.Equal(t, "-----BEGIN CERTIFICATE-----", string(cert))
+
+	_, ok := lookup("tls/")
+	require.False(t, ok)
+}
+
+func TestArchiveFSErrors(t *testing.T) {
+	dir := t.TempDir()
+
+	_, err := env.ArchiveFS(filepath.Join(dir, "secrets.rar"))
+	require.EqualError(t, err, filepath.Join(dir, "secrets.rar")+": unsupported archive format: expected .zip, .tar, .tar.gz or .tgz")
+
+	corrupt := filepath.Join(dir, "corrupt.zip")
+	require.NoError(t, os.WriteFile(corrupt, []byte("not a zip"), 0o600))
+
+	_, err = env.ArchiveFS(corrupt)
+	require.ErrorContains(t, err, corrupt+": zip: not a valid zip file")
+}
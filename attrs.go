@@ -0,0 +1,39 @@
+//go:build go1.21
+
+package env
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+)
+
+// Attrs are structured logging attributes parsed from comma separated key=value pairs, for example LOG_FIELDS=service=api,env=prod.
+// Values are kept as strings.
+type Attrs []slog.Attr
+
+func (attrs Attrs) MarshalText() ([]byte, error) {
+	items := make([]string, len(attrs))
+	for i, attr := range attrs {
+		items[i] = attr.Key + "=" + attr.Value.String()
+	}
+	return []byte(strings.Join(items, ",")), nil
+}
+
+func (attrs *Attrs) UnmarshalText(text []byte) error {
+	*attrs = nil
+
+	if strings.TrimSpace(string(text)) == "" {
+		return nil
+	}
+
+	for _, elem := range strings.Split(string(text), ",") {
+		key, value, ok := strings.Cut(elem, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value but got %q", elem)
+		}
+		*attrs = append(*attrs, slog.String(strings.TrimSpace(key), strings.TrimSpace(value)))
+	}
+
+	return nil
+}
@@ -0,0 +1,32 @@
+//go:build go1.21
+
+package env_test
+
+import (
+	"log/slog"
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAttrs(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"LOG_FIELDS": "service=api, env=prod",
+			"INVALID":    "service",
+		}[name]
+		return value, ok
+	})
+
+	var fields env.Attrs
+	env.FlagVar(environment, &fields, "LOG_FIELDS")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, env.Attrs{slog.String("service", "api"), slog.String("env", "prod")}, fields)
+
+	var invalid env.Attrs
+	env.FlagVar(environment, &invalid, "INVALID")
+
+	require.EqualError(t, environment.Parse(), `failed to parse INVALID: expected key=value but got "service"`)
+}
@@ -0,0 +1,66 @@
+// Package azurekv provides a lookup backed by secrets stored in Azure Key Vault.
+package azurekv
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/davidmdm/env"
+)
+
+// Client is the subset of *azsecrets.Client used by Lookup.
+type Client interface {
+	GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error)
+}
+
+// Lookup returns a lookup that resolves each variable to the latest version of the secret of the same name in the vault.
+// Since secret names cannot contain underscores, they are replaced by dashes: DATABASE_URL is read from the DATABASE-URL secret.
+//
+// Secrets are fetched the first time they are requested and cached, including secrets that do not exist.
+// A missing secret is reported as not found, while any other failure such as an authorization error causes a panic.
+func Lookup(ctx context.Context, client Client) env.LookupFunc {
+	type result struct {
+		value string
+		ok    bool
+	}
+
+	var (
+		mu    sync.Mutex
+		cache = map[string]result{}
+	)
+
+	return func(name string) (string, bool) {
+		name = strings.ReplaceAll(name, "_", "-")
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if cached, ok := cache[name]; ok {
+			return cached.value, cached.ok
+		}
+
+		resp, err := client.GetSecret(ctx, name, "", nil)
+		if err != nil {
+			var respErr *azcore.ResponseError
+			if errors.As(err, &respErr) && respErr.StatusCode == http.StatusNotFound {
+				cache[name] = result{}
+				return "", false
+			}
+			panic(fmt.Errorf("azurekv: failed to get secret %s: %w", name, err))
+		}
+
+		var value string
+		if resp.Value != nil {
+			value = *resp.Value
+		}
+
+		cache[name] = result{value: value, ok: true}
+		return value, true
+	}
+}
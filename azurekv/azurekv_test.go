@@ -0,0 +1,65 @@
+package azurekv_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets"
+	"github.com/davidmdm/env"
+	"github.com/davidmdm/env/azurekv"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeClient struct {
+	secrets map[string]string
+	status  int
+	calls   map[string]int
+}
+
+func (client *fakeClient) GetSecret(ctx context.Context, name string, version string, options *azsecrets.GetSecretOptions) (azsecrets.GetSecretResponse, error) {
+	client.calls[name]++
+	if client.status != 0 {
+		return azsecrets.GetSecretResponse{}, &azcore.ResponseError{StatusCode: client.status}
+	}
+	value, ok := client.secrets[name]
+	if !ok {
+		return azsecrets.GetSecretResponse{}, &azcore.ResponseError{StatusCode: http.StatusNotFound, ErrorCode: "SecretNotFound"}
+	}
+	return azsecrets.GetSecretResponse{Secret: azsecrets.Secret{Value: &value}}, nil
+}
+
+func TestLookup(t *testing.T) {
+	client := &fakeClient{
+		secrets: map[string]string{"DATABASE-URL": "postgres://vault"},
+		calls:   map[string]int{},
+	}
+
+	environment := env.MakeEnvSet(azurekv.Lookup(context.Background(), client))
+
+	var (
+		databaseURL string
+		missing     string
+	)
+
+	env.FlagVar(environment, &databaseURL, "DATABASE_URL")
+	env.FlagVar(environment, &missing, "MISSING", env.Options[string]{DefaultValue: "fallback"})
+
+	require.NoError(t, environment.Parse())
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, "postgres://vault", databaseURL)
+	require.Equal(t, "fallback", missing)
+	require.Equal(t, map[string]int{"DATABASE-URL": 1, "MISSING": 1}, client.calls)
+}
+
+func TestLookupAuthError(t *testing.T) {
+	client := &fakeClient{status: http.StatusForbidden, calls: map[string]int{}}
+
+	lookup := azurekv.Lookup(context.Background(), client)
+
+	require.PanicsWithError(t, "azurekv: failed to get secret DATABASE-URL: "+(&azcore.ResponseError{StatusCode: http.StatusForbidden}).Error(), func() {
+		lookup("DATABASE_URL")
+	})
+}
@@ -0,0 +1,192 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// BindJSON decodes the JSON object held by the variable name into v, which must be a pointer to a struct.
+// Every exported field of v is then registered as NAME_FIELD, where FIELD is the upper-cased json name of the field,
+// so that individual fields can be overridden by their own variables when the EnvSet is parsed.
+// Fields that are not overridden keep the value decoded from JSON.
+func BindJSON(envset EnvSet, v any, name string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot bind %s into %T: expected a pointer to a struct", name, v)
+	}
+
+	name = envset.prefix + name
+
+	if data, ok := envset.composedLookup()(name); ok {
+		if err := json.Unmarshal([]byte(data), v); err != nil {
+			return fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+	}
+
+	rv = rv.Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		fieldName := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag == "-" {
+			continue
+		} else if tag != "" {
+			fieldName = tag
+		}
+
+		envset.register(name+"_"+strings.ToUpper(fieldName), flag{
+			value: reflectValue{rv.Field(i).Addr()},
+			opts:  flagOptions{fallback: rv.Field(i).Interface()},
+		})
+	}
+
+	return nil
+}
+
+// Bind registers every field of the struct pointed to by v that has an env tag, using the tag as the variable name.
+// The name may be followed by ",required" to mark the variable as required.
+//
+// Struct fields with an env tag are bound recursively with the tag as a prefix: given `env:"REDIS"` the Host field
+// of the nested struct tagged `env:"HOST"` is registered as REDIS_HOST. Prefixes accumulate through every level.
+//
+// A sep tag sets the separator of the elements of a slice field or the entries of a map field, such as `sep:";"`,
+// instead of the default comma.
+//
+// A validate tag declares comma separated rules that the parsed value must satisfy:
+//
+//	min=N, max=N       bounds for numbers, or for the length of strings, slices and maps
+//	oneof=a b c        the value must be one of the space separated strings
+//	contains=s         the string must contain s
+//	prefix=s           the string must start with s
+//	suffix=s           the string must end with s
+//
+// For example:
+//
+//	type Config struct {
+//		Port int `env:"PORT" validate:"min=1,max=65535"`
+//	}
+func Bind(envset EnvSet, v any) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot bind %T: expected a pointer to a struct", v)
+	}
+	return bindStruct(envset, rv.Elem(), envset.prefix)
+}
+
+// bindStruct registers the tagged fields of the struct rv with their names prefixed by prefix.
+func bindStruct(envset EnvSet, rv reflect.Value, prefix string) error {
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+
+		tag, ok := field.Tag.Lookup("env")
+		if !ok || !field.IsExported() {
+			continue
+		}
+
+		name, modifier, _ := strings.Cut(tag, ",")
+		if modifier != "" && modifier != "required" {
+			return fmt.Errorf("field %s: unknown env tag option %q", field.Name, modifier)
+		}
+
+		if isNestedStruct(field.Type) {
+			if modifier != "" {
+				return fmt.Errorf("field %s: env tag option %q is not supported on nested structs", field.Name, modifier)
+			}
+			if err := bindStruct(envset, rv.Field(i), prefix+name+"_"); err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			continue
+		}
+
+		opts := flagOptions{
+			required: modifier == "required",
+			fallback: rv.Field(i).Interface(),
+		}
+
+		if sep, ok := field.Tag.Lookup("sep"); ok {
+			if kind := field.Type.Kind(); kind != reflect.Slice && kind != reflect.Map || sep == "" {
+				return fmt.Errorf("field %s: sep tag requires a non-empty separator on a slice or map field", field.Name)
+			}
+			opts.separator = sep
+		}
+
+		if rules, ok := field.Tag.Lookup("validate"); ok {
+			validate, err := parseValidateTag(rules, field.Type)
+			if err != nil {
+				return fmt.Errorf("field %s: %w", field.Name, err)
+			}
+			opts.validate = validate
+		}
+
+		envset.register(prefix+name, flag{
+			value: reflectValue{rv.Field(i).Addr()},
+			opts:  opts,
+		})
+	}
+
+	return nil
+}
+
+// isNestedStruct reports whether fields of type t are bound field by field rather than parsed from a single variable.
+func isNestedStruct(t reflect.Type) bool {
+	if t.Kind() != reflect.Struct || isUnmarshaler(t) {
+		return false
+	}
+	_, ok := lookupDecoder(t)
+	return !ok
+}
+
+// VarStruct registers every exported field of the struct pointed to by v as PREFIX_FIELD, where FIELD is the name of the field
+// in screaming snake case: a DatabaseURL field with the prefix APP is registered as APP_DATABASE_URL.
+// Fields are registered without a prefix when prefix is empty. Unlike Bind no struct tags are required,
+// and fields that are not set keep their current value.
+func VarStruct(envset EnvSet, v any, prefix string) error {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Pointer || rv.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot register %T: expected a pointer to a struct", v)
+	}
+
+	if prefix != "" {
+		prefix += "_"
+	}
+
+	rv = rv.Elem()
+	for i := 0; i < rv.NumField(); i++ {
+		field := rv.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		envset.register(envset.prefix+prefix+screamingSnakeCase(field.Name), flag{
+			value: reflectValue{rv.Field(i).Addr()},
+			opts:  flagOptions{fallback: rv.Field(i).Interface()},
+		})
+	}
+
+	return nil
+}
+
+// screamingSnakeCase converts a CamelCase name to SCREAMING_SNAKE_CASE, keeping acronyms together: HTTPServerPort becomes HTTP_SERVER_PORT.
+func screamingSnakeCase(name string) string {
+	runes := []rune(name)
+
+	var builder strings.Builder
+	for i, r := range runes {
+		if i > 0 && unicode.IsUpper(r) {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || unicode.IsUpper(prev) && nextIsLower {
+				builder.WriteByte('_')
+			}
+		}
+		builder.WriteRune(unicode.ToUpper(r))
+	}
+
+	return builder.String()
+}
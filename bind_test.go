@@ -0,0 +1,288 @@
+package env_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindJSON(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"DB":      `{"host": "localhost", "port": 5432, "timeout": 1000000000}`,
+			"DB_PORT": "6543",
+		}[name]
+		return value, ok
+	})
+
+	var db struct {
+		Host    string        `json:"host"`
+		Port    int           `json:"port"`
+		Timeout time.Duration `json:"timeout"`
+		Ignored string        `json:"-"`
+	}
+
+	require.NoError(t, env.BindJSON(environment, &db, "DB"))
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, "localhost", db.Host)
+	require.Equal(t, 6543, db.Port)
+	require.Equal(t, time.Second, db.Timeout)
+	require.Equal(t, "", db.Ignored)
+}
+
+func TestBindJSONGroup(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"APP_DB":      `{"host": "localhost", "port": 5432}`,
+			"APP_DB_PORT": "6543",
+		}[name]
+		return value, ok
+	})
+
+	var db struct {
+		Host string `json:"host"`
+		Port int    `json:"port"`
+	}
+
+	require.NoError(t, env.BindJSON(environment.Group("APP"), &db, "DB"))
+	require.Equal(t, []string{"APP_DB_HOST", "APP_DB_PORT"}, environment.Names())
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "localhost", db.Host)
+	require.Equal(t, 6543, db.Port)
+}
+
+func TestBindJSONErrors(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "{", true })
+
+	var target struct{ Host string }
+	require.ErrorContains(t, env.BindJSON(environment, &target, "DB"), "failed to decode DB")
+
+	var notStruct int
+	require.EqualError(t, env.BindJSON(environment, &notStruct, "DB"), "cannot bind DB into *int: expected a pointer to a struct")
+}
+
+func TestBind(t *testing.T) {
+	var config struct {
+		Port  int    `env:"PORT" validate:"min=1,max=65535"`
+		Level string `env:"LEVEL" validate:"oneof=debug info error"`
+		Name  string `env:"NAME,required" validate:"min=3"`
+		Host  string `env:"HOST"`
+		Other string
+	}
+	config.Host = "localhost"
+
+	lookup := func(vars map[string]string) env.LookupFunc {
+		return func(name string) (string, bool) {
+			value, ok := vars[name]
+			return value, ok
+		}
+	}
+
+	environment := env.MakeEnvSet(lookup(map[string]string{"PORT": "8080", "LEVEL": "info", "NAME": "api"}))
+	require.NoError(t, env.Bind(environment, &config))
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, 8080, config.Port)
+	require.Equal(t, "info", config.Level)
+	require.Equal(t, "api", config.Name)
+	require.Equal(t, "localhost", config.Host)
+
+	environment = env.MakeEnvSet(lookup(map[string]string{"PORT": "70000", "LEVEL": "trace"}))
+	require.NoError(t, env.Bind(environment, &config))
+
+	err := environment.Parse()
+	require.ErrorContains(t, err, "invalid value for PORT: expected value to be at most 65535 but got 70000")
+	require.ErrorContains(t, err, `invalid value for LEVEL: expected value to be one of ["debug" "info" "error"] but got "trace"`)
+	require.ErrorContains(t, err, `"NAME" is required but not found`)
+}
+
+func TestBindGroup(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"REDIS_HOST": "cache.internal", "REDIS_TLS_ENABLED": "true"}[name]
+		return value, ok
+	})
+
+	var config struct {
+		Host string `env:"HOST"`
+		TLS  struct {
+			Enabled bool `env:"ENABLED"`
+		} `env:"TLS"`
+	}
+
+	require.NoError(t, env.Bind(environment.Group("REDIS"), &config))
+	require.Equal(t, []string{"REDIS_HOST", "REDIS_TLS_ENABLED"}, environment.Names())
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "cache.internal", config.Host)
+	require.True(t, config.TLS.Enabled)
+}
+
+func TestBindErrors(t *testing.T) {
+	environment := env.MakeEnvSet()
+
+	var unknownRule struct {
+		Port int `env:"PORT" validate:"between=1"`
+	}
+	require.EqualError(t, env.Bind(environment, &unknownRule), `field Port: unknown validation rule "between"`)
+
+	var wrongType struct {
+		Port int `env:"PORT" validate:"prefix=8"`
+	}
+	require.EqualError(t, env.Bind(environment, &wrongType), "field Port: prefix rule requires a string but got int")
+
+	var unknownOption struct {
+		Port int `env:"PORT,optional"`
+	}
+	require.EqualError(t, env.Bind(environment, &unknownOption), `field Port: unknown env tag option "optional"`)
+
+	var scalarSep struct {
+		Port int `env:"PORT" sep:";"`
+	}
+	require.EqualError(t, env.Bind(environment, &scalarSep), "field Port: sep tag requires a non-empty separator on a slice or map field")
+}
+
+func TestBindSeparator(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"QUERIES": "a,b;c,d",
+			"TAGS":    "x,y",
+			"LABELS":  "team=core;tier=1",
+		}[name]
+		return value, ok
+	})
+
+	var config struct {
+		Queries []string          `env:"QUERIES" sep:";"`
+		Tags    []string          `env:"TAGS"`
+		Labels  map[string]string `env:"LABELS" sep:";"`
+	}
+
+	require.NoError(t, env.Bind(environment, &config))
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, []string{"a,b", "c,d"}, config.Queries)
+	require.Equal(t, []string{"x", "y"}, config.Tags)
+	require.Equal(t, map[string]string{"team": "core", "tier": "1"}, config.Labels)
+}
+
+func TestVarStruct(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"APP_DATABASE_URL":     "postgres://localhost",
+			"APP_HTTP_SERVER_PORT": "8080",
+			"APP_TIMEOUT":          "5s",
+			"APP_TLS":              "true",
+			"APP_ID2_NAME":         "api",
+		}[name]
+		return value, ok
+	})
+
+	config := struct {
+		DatabaseURL    string
+		HTTPServerPort int
+		Timeout        time.Duration
+		TLS            bool
+		ID2Name        string
+		Replicas       int
+		internal       string
+	}{Replicas: 3}
+
+	require.NoError(t, env.VarStruct(environment, &config, "APP"))
+	require.Equal(
+		t,
+		[]string{"APP_DATABASE_URL", "APP_HTTP_SERVER_PORT", "APP_ID2_NAME", "APP_REPLICAS", "APP_TIMEOUT", "APP_TLS"},
+		environment.Names(),
+	)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "postgres://localhost", config.DatabaseURL)
+	require.Equal(t, 8080, config.HTTPServerPort)
+	require.Equal(t, 5*time.Second, config.Timeout)
+	require.True(t, config.TLS)
+	require.Equal(t, "api", config.ID2Name)
+	require.Equal(t, 3, config.Replicas)
+	require.Equal(t, "", config.internal)
+
+	var notStruct int
+	require.EqualError(t, env.VarStruct(environment, &notStruct, "APP"), "cannot register *int: expected a pointer to a struct")
+}
+
+func TestBindNested(t *testing.T) {
+	type TLSConfig struct {
+		Enabled bool   `env:"ENABLED"`
+		Cert    string `env:"CERT,required"`
+	}
+
+	type RedisConfig struct {
+		Host    string        `env:"HOST"`
+		Port    int           `env:"PORT" validate:"min=1"`
+		Timeout time.Duration `env:"TIMEOUT"`
+		TLS     TLSConfig     `env:"TLS"`
+	}
+
+	var config struct {
+		Name  string      `env:"NAME"`
+		Redis RedisConfig `env:"REDIS"`
+		Color env.Color   `env:"COLOR"`
+	}
+	config.Redis.Port = 6379
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"NAME":              "api",
+			"REDIS_HOST":        "cache.internal",
+			"REDIS_TIMEOUT":     "2s",
+			"REDIS_TLS_ENABLED": "true",
+			"REDIS_TLS_CERT":    "/etc/tls/cert.pem",
+			"COLOR":             "#ff0000",
+		}[name]
+		return value, ok
+	})
+
+	require.NoError(t, env.Bind(environment, &config))
+	require.Equal(
+		t,
+		[]string{"COLOR", "NAME", "REDIS_HOST", "REDIS_PORT", "REDIS_TIMEOUT", "REDIS_TLS_CERT", "REDIS_TLS_ENABLED"},
+		environment.Names(),
+	)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "api", config.Name)
+	require.Equal(
+		t,
+		RedisConfig{
+			Host:    "cache.internal",
+			Port:    6379,
+			Timeout: 2 * time.Second,
+			TLS:     TLSConfig{Enabled: true, Cert: "/etc/tls/cert.pem"},
+		},
+		config.Redis,
+	)
+	require.Equal(t, env.Color{R: 0xff, A: 0xff}, config.Color)
+
+	var invalid struct {
+		Redis RedisConfig `env:"REDIS,required"`
+	}
+	require.EqualError(t, env.Bind(environment, &invalid), `field Redis: env tag option "required" is not supported on nested structs`)
+}
+
+func TestBindUnmarshalerStruct(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		return "https://example.com/api", name == "URL"
+	})
+
+	var config struct {
+		URL url.URL `env:"URL"`
+	}
+
+	require.NoError(t, env.Bind(environment, &config))
+	require.Equal(t, []string{"URL"}, environment.Names())
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "https://example.com/api", config.URL.String())
+}
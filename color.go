@@ -0,0 +1,46 @@
+package env
+
+import (
+	"encoding/hex"
+	"fmt"
+	"image/color"
+	"strings"
+)
+
+// Color is a non-alpha-premultiplied color parsed from a hex string of the form #rrggbb or #rrggbbaa,
+// like color.NRGBA. Colors without an alpha component are fully opaque. Color implements color.Color.
+type Color color.NRGBA
+
+var _ color.Color = Color{}
+
+func (c Color) RGBA() (r, g, b, a uint32) {
+	return color.NRGBA(c).RGBA()
+}
+
+func (c Color) MarshalText() ([]byte, error) {
+	if c.A == 0xff {
+		return []byte(fmt.Sprintf("#%02x%02x%02x", c.R, c.G, c.B)), nil
+	}
+	return []byte(fmt.Sprintf("#%02x%02x%02x%02x", c.R, c.G, c.B, c.A)), nil
+}
+
+func (c *Color) UnmarshalText(text []byte) error {
+	value, ok := strings.CutPrefix(string(text), "#")
+	if !ok {
+		return fmt.Errorf("invalid color %q: expected a leading #", text)
+	}
+	if len(value) != 6 && len(value) != 8 {
+		return fmt.Errorf("invalid color %q: expected 6 or 8 hex digits", text)
+	}
+
+	components, err := hex.DecodeString(value)
+	if err != nil {
+		return fmt.Errorf("invalid color %q: %w", text, err)
+	}
+	if len(components) == 3 {
+		components = append(components, 0xff)
+	}
+
+	*c = Color{R: components[0], G: components[1], B: components[2], A: components[3]}
+	return nil
+}
@@ -0,0 +1,42 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestColor(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"ACCENT":  "#ff8800",
+			"OVERLAY": "#00000080",
+			"INVALID": "#ff88zz",
+			"SHORT":   "#f80",
+		}[name]
+		return value, ok
+	})
+
+	var accent, overlay env.Color
+	env.FlagVar(environment, &accent, "ACCENT")
+	env.FlagVar(environment, &overlay, "OVERLAY")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, env.Color{R: 0xff, G: 0x88, B: 0x00, A: 0xff}, accent)
+	require.Equal(t, env.Color{R: 0x00, G: 0x00, B: 0x00, A: 0x80}, overlay)
+
+	var translucent env.Color
+	require.NoError(t, translucent.UnmarshalText([]byte("#ff000080")))
+
+	r, g, b, a := translucent.RGBA()
+	require.Equal(t, [4]uint32{0x8080, 0, 0, 0x8080}, [4]uint32{r, g, b, a})
+
+	var invalid, short env.Color
+	env.FlagVar(environment, &invalid, "INVALID")
+	env.FlagVar(environment, &short, "SHORT")
+
+	err := environment.Parse()
+	require.ErrorContains(t, err, `failed to parse INVALID: invalid color "#ff88zz": encoding/hex: invalid byte`)
+	require.ErrorContains(t, err, `failed to parse SHORT: invalid color "#f80": expected 6 or 8 hex digits`)
+}
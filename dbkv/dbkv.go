@@ -0,0 +1,46 @@
+// Package dbkv provides a lookup backed by key/value rows read from a database.
+package dbkv
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/davidmdm/env"
+)
+
+// Lookup runs query against db and returns a lookup over the rows it returns, which must have two columns: the name of each variable
+// and its value. Since the query is provided by the caller any table layout can be used, for example:
+//
+//	SELECT key, value FROM settings WHERE service = 'api'
+//
+// Rows are read once when Lookup is called. Rows with a NULL value are treated as absent.
+func Lookup(ctx context.Context, db *sql.DB, query string, args ...any) (env.LookupFunc, error) {
+	rows, err := db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query variables: %w", err)
+	}
+	defer rows.Close()
+
+	vars := map[string]string{}
+	for rows.Next() {
+		var (
+			key   string
+			value sql.NullString
+		)
+		if err := rows.Scan(&key, &value); err != nil {
+			return nil, fmt.Errorf("failed to read variable: %w", err)
+		}
+		if value.Valid {
+			vars[key] = value.String
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read variables: %w", err)
+	}
+
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}, nil
+}
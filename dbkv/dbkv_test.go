@@ -0,0 +1,147 @@
+package dbkv_test
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/davidmdm/env/dbkv"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	db := sql.OpenDB(connector{
+		"SELECT key, value FROM settings": {
+			{"HOST", "db.internal"},
+			{"PORT", "5432"},
+			{"UNSET", nil},
+		},
+	})
+	defer db.Close()
+
+	lookup, err := dbkv.Lookup(context.Background(), db, "SELECT key, value FROM settings")
+	require.NoError(t, err)
+
+	environment := env.MakeEnvSet(lookup)
+
+	var (
+		host  string
+		port  int
+		unset string
+	)
+
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &unset, "UNSET", env.Options[string]{DefaultValue: "default"})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "db.internal", host)
+	require.Equal(t, 5432, port)
+	require.Equal(t, "default", unset)
+}
+
+func TestLookupColumnTypes(t *testing.T) {
+	db := sql.OpenDB(connector{
+		"SELECT key, value FROM settings": {
+			{[]byte("HOST"), []byte("db.internal")},
+			{"PORT", int64(5432)},
+			{"RATIO", 0.5},
+			{"ENABLED", true},
+			{"EMPTY", []byte{}},
+			{"UNSET", nil},
+		},
+	})
+	defer db.Close()
+
+	lookup, err := dbkv.Lookup(context.Background(), db, "SELECT key, value FROM settings")
+	require.NoError(t, err)
+
+	for name, expected := range map[string]string{
+		"HOST":    "db.internal",
+		"PORT":    "5432",
+		"RATIO":   "0.5",
+		"ENABLED": "true",
+		"EMPTY":   "",
+	} {
+		value, ok := lookup(name)
+		require.True(t, ok, name)
+		require.Equal(t, expected, value, name)
+	}
+
+	_, ok := lookup("UNSET")
+	require.False(t, ok)
+}
+
+func TestLookupErrors(t *testing.T) {
+	db := sql.OpenDB(connector{
+		"SELECT key FROM settings": {{"HOST"}},
+	})
+	defer db.Close()
+
+	_, err := dbkv.Lookup(context.Background(), db, "SELECT key FROM settings")
+	require.ErrorContains(t, err, "failed to read variable: sql: expected 1 destination arguments in Scan, not 2")
+
+	_, err = dbkv.Lookup(context.Background(), db, "SELECT * FROM missing")
+	require.EqualError(t, err, "failed to query variables: no such table")
+}
+
+// connector is a minimal database/sql driver serving fixed rows for known queries. It stands in for a real database such as
+// SQLite, whose drivers would add a heavy or cgo dependency to the module, so rows use the driver.Value types that drivers
+// return: []byte, string, int64, float64, bool and nil for NULL.
+type connector map[string][][]driver.Value
+
+func (c connector) Connect(context.Context) (driver.Conn, error) { return conn(c), nil }
+func (c connector) Driver() driver.Driver                        { return nil }
+
+type conn map[string][][]driver.Value
+
+func (c conn) Prepare(query string) (driver.Stmt, error) {
+	values, ok := c[query]
+	if !ok {
+		return nil, errors.New("no such table")
+	}
+	return stmt(values), nil
+}
+
+func (conn) Close() error              { return nil }
+func (conn) Begin() (driver.Tx, error) { return nil, errors.New("transactions are not supported") }
+
+type stmt [][]driver.Value
+
+func (stmt) Close() error  { return nil }
+func (stmt) NumInput() int { return -1 }
+
+func (stmt) Exec([]driver.Value) (driver.Result, error) {
+	return nil, errors.New("exec is not supported")
+}
+
+func (s stmt) Query([]driver.Value) (driver.Rows, error) {
+	return &rows{values: s}, nil
+}
+
+type rows struct {
+	values [][]driver.Value
+	i      int
+}
+
+func (r *rows) Columns() []string {
+	if len(r.values) == 0 {
+		return nil
+	}
+	return []string{"key", "value"}[:len(r.values[0])]
+}
+
+func (r *rows) Close() error { return nil }
+
+func (r *rows) Next(dest []driver.Value) error {
+	if r.i == len(r.values) {
+		return io.EOF
+	}
+	copy(dest, r.values[r.i])
+	r.i++
+	return nil
+}
@@ -0,0 +1,136 @@
+package env
+
+import (
+	"fmt"
+	"net/mail"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type decoder func(text string, v reflect.Value, opts flagOptions) error
+
+var decoders = struct {
+	sync.RWMutex
+	m map[reflect.Type]decoder
+}{
+	m: map[reflect.Type]decoder{
+		reflect.TypeOf(time.Duration(0)):  decodeDuration,
+		reflect.TypeOf(mail.Address{}):    decodeMailAddress,
+		reflect.TypeOf([]*mail.Address{}): decodeMailAddressList,
+		reflect.TypeOf(time.Weekday(0)):   decodeWeekday,
+		reflect.TypeOf(time.Month(0)):     decodeMonth,
+		reflect.TypeOf(os.FileMode(0)):    decodeFileMode,
+	},
+}
+
+// RegisterDecoder registers fn as the parser for values of type t, allowing types from third-party packages
+// to be used as destinations without implementing encoding.TextUnmarshaler.
+// fn receives the text to parse and an addressable value of type t to set.
+// Registering a decoder for a type that already has one replaces it.
+func RegisterDecoder(t reflect.Type, fn func(string, reflect.Value) error) {
+	decoders.Lock()
+	defer decoders.Unlock()
+	decoders.m[t] = func(text string, v reflect.Value, _ flagOptions) error {
+		return fn(text, v)
+	}
+}
+
+func lookupDecoder(t reflect.Type) (decoder, bool) {
+	decoders.RLock()
+	defer decoders.RUnlock()
+	decode, ok := decoders.m[t]
+	return decode, ok
+}
+
+func decodeDuration(text string, v reflect.Value, opts flagOptions) error {
+	if opts.requireUnit {
+		if _, err := strconv.ParseFloat(strings.TrimSpace(text), 64); err == nil {
+			return fmt.Errorf("duration requires a unit (e.g. %ss)", strings.TrimSpace(text))
+		}
+	}
+	if opts.extendedDuration {
+		text = expandDuration(text)
+	}
+	d, err := time.ParseDuration(text)
+	if err != nil {
+		return err
+	}
+	v.SetInt(int64(d))
+	return nil
+}
+
+func decodeMailAddress(text string, v reflect.Value, _ flagOptions) error {
+	addr, err := mail.ParseAddress(text)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(*addr))
+	return nil
+}
+
+func decodeMailAddressList(text string, v reflect.Value, _ flagOptions) error {
+	addrs, err := mail.ParseAddressList(text)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(addrs))
+	return nil
+}
+
+// decodeWeekday parses a day of the week from its English name, case-insensitively, or from its number where Sunday is 0.
+func decodeWeekday(text string, v reflect.Value, _ flagOptions) error {
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.EqualFold(text, day.String()) {
+			v.SetInt(int64(day))
+			return nil
+		}
+	}
+	if n, err := strconv.Atoi(text); err == nil && n >= int(time.Sunday) && n <= int(time.Saturday) {
+		v.SetInt(int64(n))
+		return nil
+	}
+	return fmt.Errorf("invalid weekday %q", text)
+}
+
+// decodeMonth parses a month from its English name, case-insensitively, or from its number where January is 1.
+func decodeMonth(text string, v reflect.Value, _ flagOptions) error {
+	for month := time.January; month <= time.December; month++ {
+		if strings.EqualFold(text, month.String()) {
+			v.SetInt(int64(month))
+			return nil
+		}
+	}
+	if n, err := strconv.Atoi(text); err == nil && n >= int(time.January) && n <= int(time.December) {
+		v.SetInt(int64(n))
+		return nil
+	}
+	return fmt.Errorf("invalid month %q", text)
+}
+
+// decodeFileMode parses permission bits from octal, such as 0644 or 0o755, or from their symbolic form such as rwxr-xr-x.
+func decodeFileMode(text string, v reflect.Value, _ flagOptions) error {
+	if len(text) == 9 && strings.Trim(text, "rwx-") == "" {
+		var mode os.FileMode
+		for i, c := range text {
+			if c != '-' {
+				if c != rune("rwx"[i%3]) {
+					return fmt.Errorf("invalid file mode %q", text)
+				}
+				mode |= 1 << (8 - i)
+			}
+		}
+		v.SetUint(uint64(mode))
+		return nil
+	}
+
+	mode, err := strconv.ParseUint(strings.TrimPrefix(text, "0o"), 8, 32)
+	if err != nil || mode > uint64(os.ModePerm) {
+		return fmt.Errorf("invalid file mode %q", text)
+	}
+	v.SetUint(mode)
+	return nil
+}
@@ -0,0 +1,187 @@
+package env
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// DotEnv reads a dotenv document from r and returns a lookup over the variables it defines.
+//
+// Each non-blank line is of the form KEY=VALUE and may be prefixed by export. Lines starting with # are comments,
+// as is anything following a # preceded by whitespace in an unquoted value. Single-quoted values are taken literally
+// while double-quoted values support the \n, \r, \t, \", and \\ escapes.
+func DotEnv(r io.Reader) (LookupFunc, error) {
+	vars, err := parseDotEnv(r)
+	if err != nil {
+		return nil, err
+	}
+	return mapLookup(vars), nil
+}
+
+// EmbeddedDotEnv parses content as a dotenv document, such as defaults shipped with the binary using go:embed:
+//
+//	//go:embed defaults.env
+//	var defaults string
+//
+// The returned lookup is meant as the source of lowest precedence, for example with EnvSet.SetDefaultLookup,
+// so that the environment and command line args override the embedded values.
+func EmbeddedDotEnv(content string) (LookupFunc, error) {
+	return DotEnv(strings.NewReader(content))
+}
+
+// MakeEnvSetWithDotEnv is like MakeEnvSet but falls back to the variables of the dotenv file at path,
+// so that the environment or any provided lookup functions take precedence over the file.
+// A missing file is ignored, while a malformed one is reported as an error.
+func MakeEnvSetWithDotEnv(path string, funcs ...LookupFunc) (EnvSet, error) {
+	vars, err := readDotEnvFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return EnvSet{}, err
+	}
+
+	envset := MakeEnvSet(funcs...)
+	if vars != nil {
+		sourceKeys := envset.sourceKeys
+		envset.SetLookupFunc(append(envset.sources, mapLookup(vars))...)
+		envset.sourceKeys = append(sourceKeys, func() []string { return mapKeys(vars) })
+	}
+
+	return envset, nil
+}
+
+// DotEnvFiles reads the dotenv files at paths and returns a single lookup over their variables,
+// where files that come later override the variables of earlier ones, for example DotEnvFiles(".env", ".env.local").
+// Missing files are skipped, while malformed ones are reported as an error.
+func DotEnvFiles(paths ...string) (LookupFunc, error) {
+	merged := map[string]string{}
+	for _, path := range paths {
+		vars, err := readDotEnvFile(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		for key, value := range vars {
+			merged[key] = value
+		}
+	}
+	return mapLookup(merged), nil
+}
+
+func mapKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func readDotEnvFile(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	vars, err := parseDotEnv(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return vars, nil
+}
+
+func parseDotEnv(r io.Reader) (map[string]string, error) {
+	var (
+		vars    = map[string]string{}
+		scanner = bufio.NewScanner(r)
+		line    = 0
+	)
+
+	for scanner.Scan() {
+		line++
+
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		key, value, ok := strings.Cut(strings.TrimPrefix(text, "export "), "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: expected KEY=VALUE", line)
+		}
+
+		key = strings.TrimSpace(key)
+		if !isEnvName(key) {
+			return nil, fmt.Errorf("line %d: invalid variable name %q", line, key)
+		}
+
+		value, err := unquoteDotEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+
+		vars[key] = value
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return vars, nil
+}
+
+func unquoteDotEnvValue(value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+
+	switch quote := value[0]; quote {
+	case '\'':
+		end := strings.IndexByte(value[1:], quote)
+		if end == -1 {
+			return "", fmt.Errorf("unterminated quoted value")
+		}
+		return value[1 : end+1], nil
+
+	case '"':
+		var result strings.Builder
+		for i := 1; i < len(value); i++ {
+			switch c := value[i]; c {
+			case '"':
+				return result.String(), nil
+			case '\\':
+				i++
+				if i == len(value) {
+					break
+				}
+				switch value[i] {
+				case 'n':
+					result.WriteByte('\n')
+				case 'r':
+					result.WriteByte('\r')
+				case 't':
+					result.WriteByte('\t')
+				case '"', '\\':
+					result.WriteByte(value[i])
+				default:
+					result.WriteByte('\\')
+					result.WriteByte(value[i])
+				}
+			default:
+				result.WriteByte(c)
+			}
+		}
+		return "", fmt.Errorf("unterminated quoted value")
+
+	default:
+		if idx := strings.Index(value, " #"); idx != -1 {
+			value = value[:idx]
+		}
+		return strings.TrimSpace(value), nil
+	}
+}
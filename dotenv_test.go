@@ -0,0 +1,166 @@
+package env_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDotEnv(t *testing.T) {
+	content := `
+# comment
+export DATABASE_URL=postgres://localhost/db
+NAME = api # trailing comment
+SINGLE='literal \n value'
+DOUBLE="line\nbreak \"quoted\""
+EMPTY=
+`
+
+	lookup, err := env.DotEnv(strings.NewReader(content))
+	require.NoError(t, err)
+
+	for name, expected := range map[string]string{
+		"DATABASE_URL": "postgres://localhost/db",
+		"NAME":         "api",
+		"SINGLE":       `literal \n value`,
+		"DOUBLE":       "line\nbreak \"quoted\"",
+		"EMPTY":        "",
+	} {
+		value, ok := lookup(name)
+		require.True(t, ok, name)
+		require.Equal(t, expected, value, name)
+	}
+}
+
+func TestDotEnvErrors(t *testing.T) {
+	for content, expected := range map[string]string{
+		"A=1\nB\n":    "line 2: expected KEY=VALUE",
+		"1A=1\n":      `line 1: invalid variable name "1A"`,
+		"A=\"open\n":  "line 1: unterminated quoted value",
+		"A=1\nB='x\n": "line 2: unterminated quoted value",
+	} {
+		_, err := env.DotEnv(strings.NewReader(content))
+		require.EqualError(t, err, expected)
+	}
+}
+
+func TestMakeEnvSetWithDotEnv(t *testing.T) {
+	dir := t.TempDir()
+
+	path := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(path, []byte("PORT=8080\nHOST=localhost\n"), 0o644))
+
+	environment, err := env.MakeEnvSetWithDotEnv(path, env.CommandLineArgs("--port=9090"))
+	require.NoError(t, err)
+
+	var (
+		port int
+		host string
+	)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &host, "HOST")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 9090, port)
+	require.Equal(t, "localhost", host)
+
+	environment, err = env.MakeEnvSetWithDotEnv(filepath.Join(dir, "missing.env"), env.CommandLineArgs("--port=9090"))
+	require.NoError(t, err)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &host, "HOST")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 9090, port)
+	require.Equal(t, "", host)
+
+	malformed := filepath.Join(dir, "malformed.env")
+	require.NoError(t, os.WriteFile(malformed, []byte("PORT\n"), 0o644))
+
+	_, err = env.MakeEnvSetWithDotEnv(malformed)
+	require.EqualError(t, err, malformed+": line 1: expected KEY=VALUE")
+}
+
+func TestDotEnvFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	base := filepath.Join(dir, ".env")
+	require.NoError(t, os.WriteFile(base, []byte("PORT=8080\nHOST=localhost\n"), 0o644))
+
+	local := filepath.Join(dir, ".env.local")
+	require.NoError(t, os.WriteFile(local, []byte("PORT=9090\n"), 0o644))
+
+	lookup, err := env.DotEnvFiles(base, filepath.Join(dir, "missing.env"), local)
+	require.NoError(t, err)
+
+	port, ok := lookup("PORT")
+	require.True(t, ok)
+	require.Equal(t, "9090", port)
+
+	host, ok := lookup("HOST")
+	require.True(t, ok)
+	require.Equal(t, "localhost", host)
+
+	malformed := filepath.Join(dir, "malformed.env")
+	require.NoError(t, os.WriteFile(malformed, []byte("PORT\n"), 0o644))
+
+	_, err = env.DotEnvFiles(base, malformed)
+	require.EqualError(t, err, malformed+": line 1: expected KEY=VALUE")
+}
+
+func TestEmbeddedDotEnv(t *testing.T) {
+	defaults, err := env.EmbeddedDotEnv("PORT=8080\nHOST=localhost\n")
+	require.NoError(t, err)
+
+	environment := env.MakeEnvSet(env.CommandLineArgs("--port=9090"))
+	environment.SetDefaultLookup(defaults)
+
+	var (
+		port int
+		host string
+	)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &host, "HOST")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 9090, port)
+	require.Equal(t, "localhost", host)
+
+	_, err = env.EmbeddedDotEnv("PORT\n")
+	require.EqualError(t, err, "line 1: expected KEY=VALUE")
+}
+
+func TestMakeEnvSetWithDotEnvPrefix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), ".env")
+	require.NoError(t, os.WriteFile(path, []byte("DOTENV_FEATURE_X=on\nDOTENV_FEATURE_Y=off\n"), 0o644))
+
+	t.Setenv("DOTENV_FEATURE_Y", "on")
+	t.Setenv("DOTENV_FEATURE_Z", "on")
+
+	environment, err := env.MakeEnvSetWithDotEnv(path)
+	require.NoError(t, err)
+
+	var features map[string]string
+	env.VarPrefix(environment, &features, "DOTENV_FEATURE_")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]string{"X": "on", "Y": "on", "Z": "on"}, features)
+
+	environment, err = env.MakeEnvSetWithDotEnv(path, env.CommandLineArgs("--verbose"))
+	require.NoError(t, err)
+
+	env.VarPrefix(environment, &features, "DOTENV_FEATURE_")
+	require.EqualError(t, environment.Parse(), "cannot collect variables with prefix DOTENV_FEATURE_: the lookup cannot be enumerated")
+
+	environment = env.MakeEnvSet()
+	environment.SetLookupFunc(env.CommandLineArgs("--verbose"))
+
+	env.VarPrefix(environment, &features, "DOTENV_FEATURE_")
+	require.EqualError(t, environment.Parse(), "cannot collect variables with prefix DOTENV_FEATURE_: the lookup cannot be enumerated")
+}
@@ -0,0 +1,76 @@
+package env
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// Endpoint is a network endpoint given either as a URL such as https://api.example.com/v1 or as a bare host:port
+// such as api.example.com:8443, normalized into its components. A host is required.
+// The port of http and https URLs defaults to 80 and 443 respectively, otherwise it is zero when unspecified.
+type Endpoint struct {
+	Scheme string
+	Host   string
+	Port   int
+	Path   string
+}
+
+// Address returns the host and port of the endpoint joined as host:port, or the host alone when the port is unspecified.
+func (e Endpoint) Address() string {
+	if e.Port == 0 {
+		return e.Host
+	}
+	return net.JoinHostPort(e.Host, strconv.Itoa(e.Port))
+}
+
+func (e Endpoint) String() string {
+	if e.Scheme == "" {
+		return e.Address()
+	}
+	return e.Scheme + "://" + e.Address() + e.Path
+}
+
+func (e Endpoint) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e *Endpoint) UnmarshalText(text []byte) error {
+	value := strings.TrimSpace(string(text))
+
+	var (
+		result Endpoint
+		port   string
+	)
+
+	if strings.Contains(value, "://") {
+		u, err := url.Parse(value)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint %q: %w", value, err)
+		}
+		result.Scheme, result.Host, result.Path, port = strings.ToLower(u.Scheme), u.Hostname(), u.Path, u.Port()
+		if port == "" {
+			port = map[string]string{"http": "80", "https": "443"}[result.Scheme]
+		}
+	} else if host, p, err := net.SplitHostPort(value); err == nil {
+		result.Host, port = host, p
+	} else {
+		result.Host = value
+	}
+
+	if result.Host == "" {
+		return fmt.Errorf("invalid endpoint %q: missing host", value)
+	}
+	if port != "" {
+		n, err := strconv.ParseUint(port, 10, 16)
+		if err != nil {
+			return fmt.Errorf("invalid endpoint %q: invalid port %q", value, port)
+		}
+		result.Port = int(n)
+	}
+
+	*e = result
+	return nil
+}
@@ -0,0 +1,69 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEndpoint(t *testing.T) {
+	for _, tc := range []struct {
+		Value    string
+		Expected env.Endpoint
+		Address  string
+		String   string
+	}{
+		{
+			Value:    "https://api.example.com/v1",
+			Expected: env.Endpoint{Scheme: "https", Host: "api.example.com", Port: 443, Path: "/v1"},
+			Address:  "api.example.com:443",
+			String:   "https://api.example.com:443/v1",
+		},
+		{
+			Value:    "grpc://[::1]:9090",
+			Expected: env.Endpoint{Scheme: "grpc", Host: "::1", Port: 9090},
+			Address:  "[::1]:9090",
+			String:   "grpc://[::1]:9090",
+		},
+		{
+			Value:    "api.example.com:8443",
+			Expected: env.Endpoint{Host: "api.example.com", Port: 8443},
+			Address:  "api.example.com:8443",
+			String:   "api.example.com:8443",
+		},
+		{
+			Value:    "localhost",
+			Expected: env.Endpoint{Host: "localhost"},
+			Address:  "localhost",
+			String:   "localhost",
+		},
+	} {
+		t.Run(tc.Value, func(t *testing.T) {
+			environment := env.MakeEnvSet(func(string) (string, bool) { return tc.Value, true })
+
+			var endpoint env.Endpoint
+			env.FlagVar(environment, &endpoint, "ENDPOINT")
+
+			require.NoError(t, environment.Parse())
+			require.Equal(t, tc.Expected, endpoint)
+			require.Equal(t, tc.Address, endpoint.Address())
+			require.Equal(t, tc.String, endpoint.String())
+		})
+	}
+}
+
+func TestEndpointErrors(t *testing.T) {
+	for _, tc := range []struct {
+		Value string
+		Error string
+	}{
+		{Value: "https:///v1", Error: `invalid endpoint "https:///v1": missing host`},
+		{Value: ":8080", Error: `invalid endpoint ":8080": missing host`},
+		{Value: "localhost:http", Error: `invalid endpoint "localhost:http": invalid port "http"`},
+		{Value: "localhost:70000", Error: `invalid endpoint "localhost:70000": invalid port "70000"`},
+	} {
+		var endpoint env.Endpoint
+		require.EqualError(t, endpoint.UnmarshalText([]byte(tc.Value)), tc.Error)
+	}
+}
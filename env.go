@@ -1,22 +1,79 @@
 package env
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
+	"text/tabwriter"
+	"time"
 )
 
+// redacted replaces the values of secret variables wherever they are reported.
+const redacted = "[redacted]"
+
 type (
 	LookupFunc func(string) (string, bool)
 	EnvSet     struct {
-		flags  map[string]flag
-		lookup LookupFunc
+		name     string
+		prefix   string
+		flags    map[string]flag
+		prefixes map[string]collector
+		keys     func() []string
+		frozen   *bool
+		lookup   LookupFunc
+		sources  []LookupFunc
+		warnings *[]string
+
+		consolidateRequired bool
+		onParse             func(name, raw string, err error)
+		constraints         *[]constraint
+		preprocess          func(name, raw string) string
+		decryptor           Decryptor
+		defaults            LookupFunc
+		metrics             func(name string, dur time.Duration, err error)
+		strictDefaults      bool
+		finalizers          *[]func() error
+		overrides           *[]LookupFunc
+		closers             *[]io.Closer
+		sourceKeys          []func() []string
 	}
 )
 
+// Decryptor decrypts values found by the lookup that are marked as encrypted. See EnvSet.WithDecryptor.
+type Decryptor interface {
+	Decrypt(ctx context.Context, ciphertext string) (string, error)
+}
+
+// encryptedPrefix marks values to be decrypted by the Decryptor of the EnvSet.
+const encryptedPrefix = "enc:"
+
+// collector sets the variables collected by prefix, keyed by the rest of their name, into its destination.
+// When dryRun is set the values are only checked.
+type collector func(values map[string]string, dryRun bool) error
+
+// constraint checks a relationship between variables given a function reporting whether a variable was provided.
+type constraint func(present func(name string) bool) error
+
 func MakeEnvSet(funcs ...LookupFunc) EnvSet {
+	envset := MakeEnvSetIsolated(funcs...)
+	if len(envset.sources) == 0 {
+		envset.SetLookupFunc(os.LookupEnv)
+		envset.sourceKeys = []func() []string{environKeys}
+	}
+	return envset
+}
+
+// MakeEnvSetIsolated is like MakeEnvSet but never falls back to the process environment:
+// variables are only looked up in funcs, and none are found when funcs is empty.
+// It prevents tests from accidentally reading the real environment.
+func MakeEnvSetIsolated(funcs ...LookupFunc) EnvSet {
 	lookupFuncs := make([]LookupFunc, 0, len(funcs))
 	for _, fn := range funcs {
 		if fn == nil {
@@ -25,42 +82,821 @@ func MakeEnvSet(funcs ...LookupFunc) EnvSet {
 		lookupFuncs = append(lookupFuncs, fn)
 	}
 
-	lookup := os.LookupEnv
-	if len(lookupFuncs) > 0 {
-		lookup = joinLookupFuncs(lookupFuncs...)
-	}
-
 	return EnvSet{
-		flags:  make(map[string]flag),
-		lookup: lookup,
+		flags:       make(map[string]flag),
+		prefixes:    make(map[string]collector),
+		frozen:      new(bool),
+		constraints: new([]constraint),
+		finalizers:  new([]func() error),
+		overrides:   new([]LookupFunc),
+		closers:     new([]io.Closer),
+		lookup:      joinLookupFuncs(lookupFuncs...),
+		sources:     lookupFuncs,
+		sourceKeys:  make([]func() []string, len(lookupFuncs)),
+		warnings:    new([]string),
 	}
 }
 
+// SetName names the EnvSet. The name prefixes the errors and warnings it reports, which helps to tell sets apart
+// in applications composed of several of them.
+func (env *EnvSet) SetName(name string) {
+	env.name = name
+}
+
 func (env *EnvSet) SetLookupFunc(fns ...LookupFunc) {
 	env.lookup = joinLookupFuncs(fns...)
+	env.sources = fns
+	env.sourceKeys = make([]func() []string, len(fns))
+}
+
+// Group returns an EnvSet that registers its variables on env with names prefixed by prefix and an underscore.
+// For example a PORT variable registered through env.Group("REDIS") is looked up as REDIS_PORT. Groups can be nested.
+// The group shares the variables and the lookup of env, so parsing either one parses both.
+// Constraints such as RequireAllOrNone are shared too, and their names are prefixed like those of variables.
+// Other settings, such as SetName or OnParse, only apply when parsing through the EnvSet they were set on.
+func (env EnvSet) Group(prefix string) EnvSet {
+	env.prefix += prefix + "_"
+	return env
+}
+
+// prefixed returns names with the prefix of the group applied.
+func (env EnvSet) prefixed(names []string) []string {
+	if env.prefix == "" {
+		return names
+	}
+	result := make([]string, len(names))
+	for i, name := range names {
+		result[i] = env.prefix + name
+	}
+	return result
+}
+
+// SetDefaultLookup registers fn to provide the values of variables that are not found by the lookup of the EnvSet,
+// for example defaults shipped in a configuration file that the environment can override.
+// Values found by fn are parsed like any other and satisfy Required variables,
+// while the DefaultValue option is only used when fn does not find the variable either.
+func (env *EnvSet) SetDefaultLookup(fn LookupFunc) {
+	env.defaults = fn
+}
+
+// SetKeysFunc registers fn to list the names of the variables that the lookup of the EnvSet can find,
+// which VarPrefix requires to collect variables by prefix. Without it the names are listed from the sources of the EnvSet,
+// which is only possible when every source can be enumerated: the process environment that EnvSets created without
+// lookup functions fall back to, and the file of MakeEnvSetWithDotEnv. Collecting fails otherwise.
+func (env *EnvSet) SetKeysFunc(fn func() []string) {
+	env.keys = fn
+}
+
+// Freeze prevents further variables from being registered on the EnvSet: registering one afterwards panics.
+// It catches wiring bugs such as variables registered after the configuration was parsed. Parse keeps working.
+// Freezing applies to every copy of the EnvSet, including its groups.
+func (env EnvSet) Freeze() {
+	*env.frozen = true
+}
+
+// PushOverride layers lookup on top of every other source of the EnvSet until it is removed by PopOverride,
+// so that parsing again picks up the values it overrides. Overrides pushed later take precedence.
+// Like Freeze, overrides apply to every copy of the EnvSet.
+func (env EnvSet) PushOverride(lookup LookupFunc) {
+	*env.overrides = append(*env.overrides, lookup)
+}
+
+// PopOverride removes the override pushed last. It does nothing if there are no overrides.
+func (env EnvSet) PopOverride() {
+	if n := len(*env.overrides); n > 0 {
+		*env.overrides = (*env.overrides)[:n-1]
+	}
+}
+
+// Lookup returns the lookup function used by the EnvSet to resolve variables, including the overrides pushed with PushOverride.
+func (env EnvSet) Lookup() LookupFunc {
+	return env.composedLookup()
+}
+
+// SetRequiredErrorMode controls how missing required variables are reported by Parse.
+// When consolidated is true they are grouped into a single error instead of one error per variable.
+func (env *EnvSet) SetRequiredErrorMode(consolidated bool) {
+	env.consolidateRequired = consolidated
 }
 
 func (env EnvSet) Parse() error {
-	errs := make([]error, 0, len(Environment.flags))
+	return env.ParseContext(context.Background())
+}
+
+// ParseContext is like Parse but passes ctx to the Decryptor of the EnvSet.
+// If ctx can be canceled, lookups are abandoned when it is done and Parse fails with an error naming the variable being resolved.
+func (env EnvSet) ParseContext(ctx context.Context) error {
+	return env.parseFlags(ctx, false, nil)
+}
+
+// ParseTimeout is like Parse but fails if resolving the variables takes longer than timeout,
+// so that a hanging remote lookup does not block startup forever.
+func (env EnvSet) ParseTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return env.ParseContext(ctx)
+}
+
+// ParsePartial is like Parse but also returns the resolved values of every variable that did not fail, formatted as text,
+// even when others did. Variables that are not set resolve to their default value, and Secret values are redacted.
+// It is intended for diagnostics, such as a command printing the configuration that was resolved alongside what is missing.
+func (env EnvSet) ParsePartial() (resolved map[string]string, err error) {
+	report := make(map[string]resolution, len(env.flags))
+	err = env.parseFlags(context.Background(), false, report)
+
+	resolved = make(map[string]string, len(report))
+	for name, res := range report {
+		if res.err == nil {
+			resolved[name] = res.value
+		}
+	}
+	return resolved, err
+}
+
+// Describe resolves the variables like Validate, without modifying their destinations, and writes a table of the result to w:
+// the value of each variable, redacted if it is a Secret, the source it was found in, and whether its default value was used.
+// Sources are the lookup functions of the EnvSet numbered from 1 in order of precedence, the lookup of the variable or the default
+// lookup of the EnvSet, or files for the FromFiles option. Variables are sorted by name.
+// Variables that fail to resolve show <error> as their value, and their errors are returned as by Validate.
+func (env EnvSet) Describe(w io.Writer) error {
+	report := make(map[string]resolution, len(env.flags))
+	parseErr := env.parseFlags(context.Background(), true, report)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VARIABLE\tVALUE\tSOURCE\tDEFAULTED")
+	for _, name := range env.Names() {
+		res, ok := report[name]
+		if !ok {
+			continue
+		}
+
+		value, source, defaulted := res.value, res.source, "no"
+		if res.err != nil {
+			value = "<error>"
+		} else if source == "" {
+			defaulted = "yes"
+		}
+		if source == "" {
+			source = "-"
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", name, value, source, defaulted)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return parseErr
+}
+
+// resolution records how a variable was resolved during parsing.
+type resolution struct {
+	value  string
+	source string
+	err    error
+}
+
+// Validate runs the same lookups, required checks and parsing as Parse but into throwaway values,
+// reporting any errors without modifying the registered destinations.
+func (env EnvSet) Validate() error {
+	return env.parseFlags(context.Background(), true, nil)
+}
+
+// OnParse registers fn to be called for every variable during Parse, with the raw value that was found and any error
+// that occurred while resolving it. The raw value is empty when the variable was not found, and is redacted for variables
+// marked as Secret.
+func (env *EnvSet) OnParse(fn func(name, raw string, err error)) {
+	env.onParse = fn
+}
+
+// Names returns the names of the variables registered on the EnvSet, sorted.
+func (env EnvSet) Names() []string {
+	names := make([]string, 0, len(env.flags))
+	for name := range env.flags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// MissingRequired returns the names of the required variables that none of the lookups find, sorted.
+// Values are not parsed, so it is a quick check to report every missing variable at once before calling Parse.
+// Variables read from files with the FromFiles option are not reported, as the files are only read by Parse.
+func (env EnvSet) MissingRequired() []string {
+	var missing []string
 	for name, flag := range env.flags {
-		envvar, ok := env.lookup(name)
-		if !ok && flag.opts.required {
-			errs = append(errs, fmt.Errorf("%q is required but not found", name))
+		if !flag.opts.required || len(flag.opts.fromFiles) > 0 {
 			continue
 		}
-		if !ok {
-			flag.value.Set(flag.opts.fallback)
+		if _, ok := env.composedLookup()(name); ok {
 			continue
 		}
+		if flag.opts.lookup != nil {
+			if _, ok := flag.opts.lookup(name); ok {
+				continue
+			}
+		}
+		if env.defaults != nil {
+			if _, ok := env.defaults(name); ok {
+				continue
+			}
+		}
+		missing = append(missing, name)
+	}
+	sort.Strings(missing)
+	return missing
+}
 
-		if err := flag.value.Parse(envvar); err != nil {
-			errs = append(errs, fmt.Errorf("failed to parse %s: %v", name, err))
+// UnusedEnv returns the variables of the process environment that start with prefix but are not registered on the EnvSet, sorted.
+// Variables collected by VarPrefix or VarIndexed are registered. It helps catch misspelled variables such as APP_DATABSE_URL.
+func (env EnvSet) UnusedEnv(prefix string) []string {
+	var unused []string
+	for _, name := range environKeys() {
+		if _, registered := env.flags[name]; registered || !strings.HasPrefix(name, prefix) || env.isCollected(name) {
 			continue
 		}
+		unused = append(unused, name)
+	}
+	sort.Strings(unused)
+	return unused
+}
+
+// isCollected reports whether name starts with a prefix registered by VarPrefix or VarIndexed.
+func (env EnvSet) isCollected(name string) bool {
+	for prefix := range env.prefixes {
+		if suffix, ok := strings.CutPrefix(name, prefix); ok && suffix != "" {
+			return true
+		}
 	}
+	return false
+}
+
+// SetValuePreprocessor registers fn to transform every value found by the lookup before it is parsed,
+// for example to trim, unquote or decrypt values. By default values are parsed as they are found.
+func (env *EnvSet) SetValuePreprocessor(fn func(name, raw string) string) {
+	env.preprocess = fn
+}
+
+// WithDecryptor makes Parse decrypt the values prefixed with enc: using d, for example values encrypted with SOPS or age
+// in an env file. The prefix is removed before decrypting, and values without it are used as they are.
+func (env *EnvSet) WithDecryptor(d Decryptor) {
+	env.decryptor = d
+}
+
+// SetStrictDefaults makes Parse record a warning for every variable that is not set, not required, and has no default value,
+// as it silently resolves to the zero value of its type which often means that configuration was forgotten.
+// A default value equal to the zero value is treated as no default. See EnvSet.Warnings.
+func (env *EnvSet) SetStrictDefaults(strict bool) {
+	env.strictDefaults = strict
+}
+
+// WithMetrics registers recorder to be called for every variable during Parse with the time it took to look up and parse it,
+// and the error that occurred if any, for example to instrument configuration loaded from remote sources.
+func (env *EnvSet) WithMetrics(recorder func(name string, dur time.Duration, err error)) {
+	env.metrics = recorder
+}
+
+// RequireAllOrNone requires the variables names to be provided together: Parse fails if some but not all of them are present.
+func (env *EnvSet) RequireAllOrNone(names ...string) {
+	names = env.prefixed(names)
+	*env.constraints = append(*env.constraints, func(isPresent func(string) bool) error {
+		var present, missing []string
+		for _, name := range names {
+			if isPresent(name) {
+				present = append(present, name)
+			} else {
+				missing = append(missing, name)
+			}
+		}
+		if len(present) == 0 || len(missing) == 0 {
+			return nil
+		}
+		return fmt.Errorf(
+			"variables must be set together: present: %s; missing: %s",
+			strings.Join(present, ", "),
+			strings.Join(missing, ", "),
+		)
+	})
+}
+
+// MutuallyExclusive forbids the variables names from being provided together: Parse fails if more than one of them is present.
+func (env *EnvSet) MutuallyExclusive(names ...string) {
+	names = env.prefixed(names)
+	*env.constraints = append(*env.constraints, func(isPresent func(string) bool) error {
+		var present []string
+		for _, name := range names {
+			if isPresent(name) {
+				present = append(present, name)
+			}
+		}
+		if len(present) < 2 {
+			return nil
+		}
+		return fmt.Errorf("variables are mutually exclusive: present: %s", strings.Join(present, ", "))
+	})
+}
+
+// Warnings returns the warnings recorded by the last call to Parse or Validate,
+// such as variables that fell back to their default value because they failed to parse.
+func (env EnvSet) Warnings() []string {
+	return *env.warnings
+}
+
+// parseFlags parses every variable, into throwaway values when dryRun is set.
+// If report is not nil how each variable was resolved is recorded into it.
+func (env EnvSet) parseFlags(ctx context.Context, dryRun bool, report map[string]resolution) error {
+	var (
+		errs     = make([]error, 0, len(env.flags))
+		missing  []string
+		warnings []string
+		found    = make(map[string]bool, len(env.flags))
+	)
+	defer func() {
+		if env.name != "" {
+			for i, warning := range warnings {
+				warnings[i] = "[" + env.name + "] " + warning
+			}
+		}
+		*env.warnings = warnings
+	}()
+
+	for name, flag := range env.flags {
+		if dryRun {
+			flag.value = flag.value.scratch()
+		}
+
+		start := time.Now()
+		raw, source, warning, err := env.parseFlag(ctx, name, flag)
+		if env.metrics != nil {
+			env.metrics(name, time.Since(start), err)
+		}
+		found[name] = source != ""
+
+		if err == nil && !dryRun && flag.opts.onSet != nil {
+			flag.opts.onSet(raw, flag.value.Get())
+		}
+
+		if env.onParse != nil {
+			if flag.opts.secret && raw != "" {
+				raw = redacted
+			}
+			env.onParse(name, raw, err)
+		}
+
+		if warning != "" {
+			warnings = append(warnings, warning)
+		}
+
+		if report != nil {
+			res := resolution{source: source, err: err}
+			if flag.opts.secret {
+				res.value = redacted
+			} else if err == nil {
+				res.value = format(flag.value.Get())
+			}
+			report[name] = res
+		}
+
+		var missingErr missingError
+		if env.consolidateRequired && errors.As(err, &missingErr) {
+			missing = append(missing, name)
+			continue
+		}
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		if ctx.Err() != nil {
+			// The remaining variables cannot be resolved once the context is done.
+			break
+		}
+	}
+	for prefix, collect := range env.prefixes {
+		if ctx.Err() != nil {
+			break
+		}
+		collected, err := env.collectPrefix(ctx, prefix)
+		if err == nil {
+			err = collect(collected, dryRun)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if !dryRun && len(errs) == 0 && len(missing) == 0 && env.finalizers != nil {
+		for _, finalize := range *env.finalizers {
+			if err := finalize(); err != nil {
+				errs = append(errs, err)
+			}
+		}
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		errs = append(errs, fmt.Errorf("the following required variables are not set: %s", strings.Join(missing, ", ")))
+	}
+
+	present := func(name string) bool {
+		if ok, registered := found[name]; registered {
+			return ok
+		}
+		_, ok := env.composedLookup()(name)
+		return ok
+	}
+	for _, check := range *env.constraints {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := check(present); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if env.name != "" {
+		for i, err := range errs {
+			errs[i] = fmt.Errorf("[%s] %w", env.name, err)
+		}
+	}
+
 	return errors.Join(errs...)
 }
 
+// parseFlag resolves the variable name and sets it into the flag's destination.
+// It returns the raw value and the source that provided it, which is empty if it was not found,
+// and either a warning or an error when the value could not be used.
+func (env EnvSet) parseFlag(ctx context.Context, name string, flag flag) (raw, source, warning string, err error) {
+	var sources []labelledLookup
+	for i := len(*env.overrides) - 1; i >= 0; i-- {
+		sources = append(sources, labelledLookup{"override", (*env.overrides)[i]})
+	}
+	if flag.opts.lookup != nil {
+		sources = append(sources, labelledLookup{"variable lookup", flag.opts.lookup})
+	}
+	if flag.opts.mergeSources {
+		sources = append(sources, labelledLookup{"merged lookups", func(name string) (string, bool) {
+			return env.lookupAll(name, flag.opts)
+		}})
+	} else {
+		for i, fn := range env.sources {
+			sources = append(sources, labelledLookup{fmt.Sprintf("lookup %d", i+1), fn})
+		}
+	}
+	if env.defaults != nil {
+		sources = append(sources, labelledLookup{"default lookup", env.defaults})
+	}
+
+	// foundIn is only read once the lookup has returned, as it may still be running when the context is done.
+	var foundIn string
+	lookup := func(name string) (string, bool) {
+		for _, candidate := range sources {
+			if value, ok := candidate.fn(name); ok {
+				foundIn = candidate.source
+				return value, true
+			}
+		}
+		return "", false
+	}
+
+	raw, ok, err := lookupContext(ctx, lookup, name)
+	if err != nil {
+		return "", "", "", fmt.Errorf("timed out resolving %s: %w", name, err)
+	}
+	source = foundIn
+	if !ok && len(flag.opts.fromFiles) > 0 {
+		if raw, err = concatFiles(flag.opts.fromFiles); err != nil {
+			return "", "", "", fmt.Errorf("failed to read %s: %v", name, err)
+		}
+		ok, source = true, "files"
+	}
+	if !ok {
+		if flag.opts.required {
+			return "", "", "", missingError{name}
+		}
+		flag.value.Set(flag.opts.fallback)
+		if flag.opts.emptyCollection {
+			setEmptyCollection(flag.value)
+		}
+		if env.strictDefaults && isZero(flag.opts.fallback) {
+			return "", "", fmt.Sprintf("%s is not set and has no default value: using the zero value", name), nil
+		}
+		return "", "", "", nil
+	}
+
+	if ciphertext, ok := strings.CutPrefix(raw, encryptedPrefix); ok && env.decryptor != nil {
+		plaintext, err := env.decryptor.Decrypt(ctx, ciphertext)
+		if err != nil {
+			return raw, source, "", fmt.Errorf("failed to decrypt %s: %v", name, err)
+		}
+		raw = plaintext
+	}
+
+	if env.preprocess != nil {
+		raw = env.preprocess(name, raw)
+	}
+
+	if err := flag.value.Parse(raw, flag.opts); err != nil {
+		if flag.opts.fallbackOnError {
+			flag.value.Set(flag.opts.fallback)
+			return raw, source, fmt.Sprintf("failed to parse %s: %v: using default value", name, err), nil
+		}
+		return raw, source, "", fmt.Errorf("failed to parse %s: %v", name, err)
+	}
+
+	if flag.opts.dedup {
+		deduped, err := dedupItems(flag.value.Get())
+		if err != nil {
+			return raw, source, "", fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+		flag.value.Set(deduped)
+	}
+
+	if flag.opts.sorted {
+		if err := sortItems(flag.value.Get()); err != nil {
+			return raw, source, "", fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+	}
+
+	if err := checkItems(flag.value.Get(), flag.opts); err != nil {
+		return raw, source, "", fmt.Errorf("invalid value for %s: %v", name, err)
+	}
+
+	if err := checkAllowedKeys(flag.value.Get(), flag.opts); err != nil {
+		return raw, source, "", fmt.Errorf("invalid value for %s: %v", name, err)
+	}
+
+	if flag.opts.validate != nil {
+		if err := flag.opts.validate(flag.value.Get()); err != nil {
+			return raw, source, "", fmt.Errorf("invalid value for %s: %v", name, err)
+		}
+	}
+
+	return raw, source, "", nil
+}
+
+// isZero reports whether value is nil or the zero value of its type.
+func isZero(value any) bool {
+	return value == nil || reflect.ValueOf(value).IsZero()
+}
+
+// checkItems enforces the MinItems and MaxItems options on slice and map values.
+func checkItems(value any, opts flagOptions) error {
+	if opts.minItems == 0 && opts.maxItems == 0 {
+		return nil
+	}
+
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Map {
+		return nil
+	}
+
+	if count := v.Len(); count < opts.minItems {
+		return fmt.Errorf("expected at least %d items but got %d", opts.minItems, count)
+	} else if opts.maxItems > 0 && count > opts.maxItems {
+		return fmt.Errorf("expected at most %d items but got %d", opts.maxItems, count)
+	}
+	return nil
+}
+
+// dedupItems returns a copy of the slice value without its duplicate elements, in order of first occurrence.
+// Values that are not slices are returned as is.
+func dedupItems(value any) (any, error) {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice || v.IsNil() {
+		return value, nil
+	}
+	if !v.Type().Elem().Comparable() {
+		return nil, fmt.Errorf("cannot dedup elements of type %s: not comparable", v.Type().Elem())
+	}
+
+	seen := make(map[any]bool, v.Len())
+	result := reflect.MakeSlice(v.Type(), 0, v.Len())
+	for i := 0; i < v.Len(); i++ {
+		elem := v.Index(i)
+		if key := elem.Interface(); !seen[key] {
+			seen[key] = true
+			result = reflect.Append(result, elem)
+		}
+	}
+	return result.Interface(), nil
+}
+
+// sortItems sorts the elements of the slice value in place. Values that are not slices are left as is.
+func sortItems(value any) error {
+	v := reflect.ValueOf(value)
+	if v.Kind() != reflect.Slice {
+		return nil
+	}
+
+	var less func(a, b reflect.Value) bool
+	switch v.Type().Elem().Kind() {
+	case reflect.String:
+		less = func(a, b reflect.Value) bool { return a.String() < b.String() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		less = func(a, b reflect.Value) bool { return a.Int() < b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		less = func(a, b reflect.Value) bool { return a.Uint() < b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		less = func(a, b reflect.Value) bool { return a.Float() < b.Float() }
+	default:
+		return fmt.Errorf("cannot sort elements of type %s: not ordered", v.Type().Elem())
+	}
+
+	sort.SliceStable(value, func(i, j int) bool { return less(v.Index(i), v.Index(j)) })
+	return nil
+}
+
+// labelledLookup is a lookup function described by the source it represents, as reported by Describe.
+type labelledLookup struct {
+	source string
+	fn     LookupFunc
+}
+
+// lookupContext calls lookup, giving up with the error of ctx if it is done first.
+// Lookups of contexts that cannot be canceled are called directly. Panics of the lookup are propagated to the caller.
+func lookupContext(ctx context.Context, lookup LookupFunc, name string) (string, bool, error) {
+	if ctx.Done() == nil {
+		value, ok := lookup(name)
+		return value, ok, nil
+	}
+
+	type result struct {
+		value string
+		ok    bool
+		panic any
+	}
+
+	done := make(chan result, 1)
+	go func() {
+		var res result
+		defer func() {
+			res.panic = recover()
+			done <- res
+		}()
+		res.value, res.ok = lookup(name)
+	}()
+
+	select {
+	case res := <-done:
+		if res.panic != nil {
+			panic(res.panic)
+		}
+		return res.value, res.ok, nil
+	case <-ctx.Done():
+		return "", false, ctx.Err()
+	}
+}
+
+// checkAllowedKeys enforces the AllowedKeys option on map values.
+func checkAllowedKeys(value any, opts flagOptions) error {
+	v := reflect.ValueOf(value)
+	if opts.allowedKeys == nil || v.Kind() != reflect.Map {
+		return nil
+	}
+
+	allowed := make(map[string]bool, len(opts.allowedKeys))
+	for _, key := range opts.allowedKeys {
+		allowed[key] = true
+	}
+
+	var unknown []string
+	for iter := v.MapRange(); iter.Next(); {
+		if key := format(iter.Key().Interface()); !allowed[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+
+	sort.Strings(unknown)
+	return fmt.Errorf("unknown keys %q: expected any of %q", unknown, opts.allowedKeys)
+}
+
+// setEmptyCollection replaces a nil slice or map value with an empty one.
+func setEmptyCollection(v value) {
+	current := reflect.ValueOf(v.Get())
+	switch t := v.Type(); t.Kind() {
+	case reflect.Slice:
+		if !current.IsValid() || current.IsNil() {
+			v.Set(reflect.MakeSlice(t, 0, 0).Interface())
+		}
+	case reflect.Map:
+		if !current.IsValid() || current.IsNil() {
+			v.Set(reflect.MakeMap(t).Interface())
+		}
+	}
+}
+
+// concatFiles returns the contents of the files at paths joined together.
+func concatFiles(paths []string) (string, error) {
+	var builder strings.Builder
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		builder.Write(data)
+	}
+	return builder.String(), nil
+}
+
+type missingError struct{ name string }
+
+func (err missingError) Error() string {
+	return fmt.Sprintf("%q is required but not found", err.name)
+}
+
+// collectPrefix returns the values of the variables that start with prefix, keyed by the rest of their name.
+// Values are resolved like those of other variables: overrides take precedence, and lookups are abandoned when ctx is done.
+func (env EnvSet) collectPrefix(ctx context.Context, prefix string) (map[string]string, error) {
+	keys := env.keys
+	if keys == nil {
+		for _, sourceKeys := range env.sourceKeys {
+			if sourceKeys == nil {
+				return nil, fmt.Errorf("cannot collect variables with prefix %s: the lookup cannot be enumerated", prefix)
+			}
+		}
+		keys = env.listSourceKeys
+	}
+
+	lookup := env.composedLookup()
+
+	collected := map[string]string{}
+	for _, key := range keys() {
+		suffix, ok := strings.CutPrefix(key, prefix)
+		if !ok || suffix == "" {
+			continue
+		}
+		value, ok, err := lookupContext(ctx, lookup, key)
+		if err != nil {
+			return nil, fmt.Errorf("timed out resolving %s: %w", key, err)
+		}
+		if ok {
+			collected[suffix] = value
+		}
+	}
+	return collected, nil
+}
+
+// composedLookup returns the lookup of the EnvSet with its overrides layered on top, the override pushed last first.
+// Overrides are read on every call, so that the lookup reflects those pushed or popped after it was created.
+func (env EnvSet) composedLookup() LookupFunc {
+	return func(name string) (string, bool) {
+		overrides := *env.overrides
+		for i := len(overrides) - 1; i >= 0; i-- {
+			if value, ok := overrides[i](name); ok {
+				return value, true
+			}
+		}
+		return env.lookup(name)
+	}
+}
+
+// listSourceKeys lists the names found by every source of the EnvSet. sourceKeys is parallel to the sources
+// and holds nil for those that cannot be enumerated, so it must be checked beforehand.
+func (env EnvSet) listSourceKeys() []string {
+	var keys []string
+	for _, sourceKeys := range env.sourceKeys {
+		keys = append(keys, sourceKeys()...)
+	}
+	return keys
+}
+
+// environKeys lists the names of the variables of the process environment.
+func environKeys() []string {
+	environ := os.Environ()
+	keys := make([]string, 0, len(environ))
+	for _, entry := range environ {
+		name, _, _ := strings.Cut(entry, "=")
+		keys = append(keys, name)
+	}
+	return keys
+}
+
+// lookupAll joins the values found for name across every source, separated as opts expects.
+// Values from sources of lower precedence come first, so that when parsed into a map entries from sources of higher precedence win.
+// Query strings are the exception: as the first of repeated keys wins, values from sources of higher precedence come first.
+func (env EnvSet) lookupAll(name string, opts flagOptions) (string, bool) {
+	var values []string
+	for i := len(env.sources) - 1; i >= 0; i-- {
+		if value, ok := env.sources[i](name); ok {
+			values = append(values, value)
+		}
+	}
+
+	separator := opts.listSeparator()
+	if opts.queryString {
+		separator = "&"
+		for i, j := 0, len(values)-1; i < j; i, j = i+1, j-1 {
+			values[i], values[j] = values[j], values[i]
+		}
+	}
+
+	return strings.Join(values, separator), len(values) > 0
+}
+
 // MustParse is like Parse but panics if an error occurs
 func (env EnvSet) MustParse() {
 	if err := env.Parse(); err != nil {
@@ -69,9 +905,88 @@ func (env EnvSet) MustParse() {
 }
 
 func FlagVar[T any](envset EnvSet, p *T, name string, opts ...Options[T]) {
-	envset.flags[name] = flag{
+	envset.register(envset.prefix+name, flag{
 		value: genericValue[T]{p},
 		opts:  multiOpts[T](opts).toFlagOptions(),
+	})
+}
+
+// VarChoice registers a variable whose value is the name of one of choices, and sets p to the chosen value.
+// It is useful to select an implementation by name, such as a function or an interface value.
+// Values that are not a key of choices fail the parse with an error listing the valid names.
+func VarChoice[T any](envset EnvSet, p *T, name string, choices map[string]T, opts ...Options[T]) {
+	envset.register(envset.prefix+name, flag{
+		value: choiceValue[T]{p, choices},
+		opts:  multiOpts[T](opts).toFlagOptions(),
+	})
+}
+
+// VarPrefix collects every variable whose name starts with prefix into p, keyed by the rest of the name,
+// for dynamic configuration such as FEATURE_X=on and FEATURE_Y=off collected with the prefix FEATURE_ into {X: on, Y: off}.
+// Collecting requires the names of the variables to be enumerable, see EnvSet.SetKeysFunc.
+func VarPrefix(envset EnvSet, p *map[string]string, prefix string) {
+	envset.checkFrozen(envset.prefix + prefix)
+	envset.prefixes[envset.prefix+prefix] = func(values map[string]string, dryRun bool) error {
+		if !dryRun {
+			*p = values
+		}
+		return nil
+	}
+}
+
+// VarIndexed collects the variables named NAME[i] into the slice p, setting each to position i.
+// For example SERVERS[0]=a and SERVERS[2]=c produce a slice of length 3 whose element at index 1 is the zero value.
+// Elements are parsed with opts like any other variable. Collecting requires the names of the variables to be enumerable,
+// see EnvSet.SetKeysFunc.
+func VarIndexed[T any](envset EnvSet, p *[]T, name string, opts ...Options[T]) {
+	name = envset.prefix + name
+	flagOpts := multiOpts[T](opts).toFlagOptions()
+
+	envset.checkFrozen(name)
+	envset.prefixes[name+"["] = func(values map[string]string, dryRun bool) error {
+		var (
+			indexes = make(map[int]string, len(values))
+			length  = 0
+		)
+		for suffix, value := range values {
+			index, err := strconv.Atoi(strings.TrimSuffix(suffix, "]"))
+			if err != nil || index < 0 || !strings.HasSuffix(suffix, "]") {
+				return fmt.Errorf("invalid index in %s[%s", name, suffix)
+			}
+			indexes[index] = value
+			if index >= length {
+				length = index + 1
+			}
+		}
+
+		if len(indexes) == 0 {
+			return nil
+		}
+
+		elems := make([]T, length)
+		for index, value := range indexes {
+			if err := (genericValue[T]{&elems[index]}).Parse(value, flagOpts); err != nil {
+				return fmt.Errorf("failed to parse %s[%d]: %v", name, index, err)
+			}
+		}
+
+		if !dryRun {
+			*p = elems
+		}
+		return nil
+	}
+}
+
+// register adds the variable name to the EnvSet, replacing any variable of the same name.
+func (env EnvSet) register(name string, f flag) {
+	env.checkFrozen(name)
+	env.flags[name] = f
+}
+
+// checkFrozen panics if the EnvSet is frozen, as registering name would be a wiring bug.
+func (env EnvSet) checkFrozen(name string) {
+	if env.frozen != nil && *env.frozen {
+		panic(fmt.Sprintf("env: cannot register %s: the EnvSet is frozen", name))
 	}
 }
 
@@ -81,8 +996,17 @@ type flag struct {
 }
 
 var Environment = EnvSet{
-	flags:  make(map[string]flag),
-	lookup: os.LookupEnv,
+	flags:       make(map[string]flag),
+	prefixes:    make(map[string]collector),
+	frozen:      new(bool),
+	constraints: new([]constraint),
+	lookup:      os.LookupEnv,
+	finalizers:  new([]func() error),
+	overrides:   new([]LookupFunc),
+	closers:     new([]io.Closer),
+	sources:     []LookupFunc{os.LookupEnv},
+	sourceKeys:  []func() []string{environKeys},
+	warnings:    new([]string),
 }
 
 func Var[T any](p *T, name string, opts ...Options[T]) {
@@ -99,11 +1023,89 @@ func MustParse() {
 	}
 }
 
+// ParseAll parses every set and joins their errors, so that a single call validates the configuration of every module of an application.
+func ParseAll(sets ...EnvSet) error {
+	errs := make([]error, 0, len(sets))
+	for _, set := range sets {
+		errs = append(errs, set.Parse())
+	}
+	return errors.Join(errs...)
+}
+
 // CommandLineArgs returns a lookup function that will search the provided args for flags.
 // Since we often want our EnvironmentVariable name declarations to be reusable for command line args
 // the lookup is case-insensitive and all underscores are changes to dashes.
 // For example, a variable mapped to DATABASE_URL can be found using the --database-url flag when working with CommandLineArgs.
 func CommandLineArgs(args ...string) LookupFunc {
+	m := parseArgs(args, nil)
+	return func(name string) (string, bool) {
+		value, ok := m[argName(name)]
+		return strings.Join(value, ","), ok
+	}
+}
+
+// CommandLineArgsMulti is like CommandLineArgs over several sets of args, such as embedded defaults followed by the args of the user.
+// A flag found in a later set overrides the same flag in earlier sets. Unlike CommandLineArgs, empty sets are ignored
+// rather than standing for os.Args[1:].
+func CommandLineArgsMulti(argsets ...[]string) LookupFunc {
+	var lookups []LookupFunc
+	for i := len(argsets) - 1; i >= 0; i-- {
+		if len(argsets[i]) > 0 {
+			lookups = append(lookups, CommandLineArgs(argsets[i]...))
+		}
+	}
+	return joinLookupFuncs(lookups...)
+}
+
+// CommandLineArgsWithPrefix is like CommandLineArgs but only considers the flags namespaced with prefix, which is removed before matching.
+// For example, with the prefix app- the --app-port flag resolves the PORT variable, while --port is ignored.
+// The prefix is normalized like variable names, so APP_ is equivalent to app-.
+func CommandLineArgsWithPrefix(prefix string, args ...string) LookupFunc {
+	prefix = argName(prefix)
+
+	m := map[string][]string{}
+	for flag, values := range parseArgs(args, nil) {
+		if name, ok := strings.CutPrefix(flag, prefix); ok && name != "" {
+			m[name] = values
+		}
+	}
+
+	return func(name string) (string, bool) {
+		value, ok := m[argName(name)]
+		return strings.Join(value, ","), ok
+	}
+}
+
+// CommandLineArgs is like the package level CommandLineArgs except that it is aware of the variables registered on the EnvSet:
+// when a flag is repeated, variables that are not slices or maps resolve to the last value instead of all values joined by commas.
+// For example, given --port 1 --port 2 a PORT int variable resolves to 2.
+// Flags of bool variables never consume the following argument, so that in --force file.txt the positional file.txt is not taken
+// as the value of FORCE. Use --force=false to set them explicitly.
+func (env EnvSet) CommandLineArgs(args ...string) LookupFunc {
+	return func(name string) (string, bool) {
+		// args are parsed on every lookup since variables may be registered after the lookup function is created.
+		bools := map[string]bool{}
+		for name, flag := range env.flags {
+			if flag.value.Type().Kind() == reflect.Bool {
+				bools[argName(name)] = true
+			}
+		}
+
+		values, ok := parseArgs(args, bools)[argName(name)]
+		if !ok {
+			return "", false
+		}
+		if flag, registered := env.flags[name]; registered && !isCollection(flag.value.Type()) {
+			return values[len(values)-1], true
+		}
+		return strings.Join(values, ","), true
+	}
+}
+
+// parseArgs collects the values of every flag in args, in the order they appear.
+// Flags without a value are true, as are the flags in bools unless their value is given with an equals sign.
+// If args is empty os.Args[1:] is used.
+func parseArgs(args []string, bools map[string]bool) map[string][]string {
 	if len(args) == 0 {
 		args = os.Args[1:]
 	}
@@ -115,6 +1117,9 @@ func CommandLineArgs(args ...string) LookupFunc {
 
 	for _, arg := range args {
 		switch {
+		case flag != "" && isNegativeNumber(arg):
+			m[flag] = append(m[flag], arg)
+			flag = ""
 		case strings.HasPrefix(arg, "-"):
 			if flag != "" && len(m[flag]) == 0 {
 				m[flag] = []string{"true"}
@@ -123,6 +1128,9 @@ func CommandLineArgs(args ...string) LookupFunc {
 			if key, value, ok := strings.Cut(flag, "="); ok {
 				m[key] = append(m[key], value)
 				flag = ""
+			} else if bools[flag] {
+				m[flag] = append(m[flag], "true")
+				flag = ""
 			}
 		case flag == "":
 			// skip positional args
@@ -132,10 +1140,52 @@ func CommandLineArgs(args ...string) LookupFunc {
 		}
 	}
 
+	if flag != "" && len(m[flag]) == 0 {
+		m[flag] = []string{"true"}
+	}
+
+	return m
+}
+
+// isNegativeNumber reports whether arg is a negative number such as -5 or -0.5,
+// which is taken as the value of the preceding flag rather than as a flag itself.
+// Only a minus sign followed by decimal digits and at most one decimal point is matched, so that flags such as -inf are not.
+func isNegativeNumber(arg string) bool {
+	digits, ok := strings.CutPrefix(arg, "-")
+	if !ok {
+		return false
+	}
+
+	var hasDigit, hasPoint bool
+	for _, r := range digits {
+		switch {
+		case '0' <= r && r <= '9':
+			hasDigit = true
+		case r == '.' && !hasPoint:
+			hasPoint = true
+		default:
+			return false
+		}
+	}
+	return hasDigit
+}
+
+// argName converts a variable name to the name of its command line flag: DATABASE_URL becomes database-url.
+func argName(name string) string {
+	return strings.ReplaceAll(strings.ToLower(name), "_", "-")
+}
+
+// WithCaseInsensitiveEnv returns a lookup function over the process environment that also tries the upper and lower case
+// variants of a name, so that database_url and DATABASE_URL both resolve the same variable.
+// The exact name is tried first.
+func WithCaseInsensitiveEnv() LookupFunc {
 	return func(name string) (string, bool) {
-		name = strings.ReplaceAll(strings.ToLower(name), "_", "-")
-		value, ok := m[name]
-		return strings.Join(value, ","), ok
+		for _, variant := range []string{name, strings.ToUpper(name), strings.ToLower(name)} {
+			if value, ok := os.LookupEnv(variant); ok {
+				return value, true
+			}
+		}
+		return "", false
 	}
 }
 
@@ -164,6 +1214,13 @@ func FileSystem(opts FSLookupOpts) LookupFunc {
 	}
 }
 
+func mapLookup(m map[string]string) LookupFunc {
+	return func(key string) (string, bool) {
+		value, ok := m[key]
+		return value, ok
+	}
+}
+
 func joinLookupFuncs(fns ...LookupFunc) LookupFunc {
 	return func(key string) (value string, ok bool) {
 		for _, fn := range fns {
@@ -1,14 +1,27 @@
 package env_test
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math/big"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
 
 	"github.com/davidmdm/env"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
 )
 
@@ -197,3 +210,2079 @@ func TestMultipleLookups(t *testing.T) {
 	require.Equal(t, 42, max)
 	require.Equal(t, "bob", name)
 }
+
+func TestExtendedDuration(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected time.Duration
+	}{
+		{Input: "1d", Expected: 24 * time.Hour},
+		{Input: "2w", Expected: 336 * time.Hour},
+		{Input: "1d12h", Expected: 36 * time.Hour},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Input, func(t *testing.T) {
+			environment := env.MakeEnvSet(func(string) (string, bool) { return tc.Input, true })
+
+			var duration time.Duration
+			env.FlagVar(environment, &duration, "DURATION", env.Options[time.Duration]{ExtendedDuration: true})
+
+			require.NoError(t, environment.Parse())
+			require.Equal(t, tc.Expected, duration)
+		})
+	}
+
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "1d", true })
+
+	var duration time.Duration
+	env.FlagVar(environment, &duration, "DURATION")
+
+	require.ErrorContains(t, environment.Parse(), `unknown unit "d"`)
+}
+
+func TestLookup(t *testing.T) {
+	environment := env.MakeEnvSet(env.CommandLineArgs("--name=bob"))
+
+	value, ok := environment.Lookup()("NAME")
+	require.True(t, ok)
+	require.Equal(t, "bob", value)
+
+	environment.SetLookupFunc(env.CommandLineArgs("--name=alice"))
+
+	value, ok = environment.Lookup()("NAME")
+	require.True(t, ok)
+	require.Equal(t, "alice", value)
+
+	_, ok = environment.Lookup()("MISSING")
+	require.False(t, ok)
+}
+
+func TestMapKeyTransform(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "Accept=1,X-Request-ID=2", true })
+
+	var headers map[string]int
+	env.FlagVar(environment, &headers, "HEADERS", env.Options[map[string]int]{KeyTransform: strings.ToLower})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]int{"accept": 1, "x-request-id": 2}, headers)
+}
+
+type LogLevel int
+
+var _ flag.Value = new(LogLevel)
+
+func (level LogLevel) String() string {
+	return [...]string{"debug", "info", "error"}[level]
+}
+
+func (level *LogLevel) Set(value string) error {
+	for i, name := range [...]string{"debug", "info", "error"} {
+		if name == value {
+			*level = LogLevel(i)
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown level: %s", value)
+}
+
+func TestFlagValue(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "error", true })
+
+	var level LogLevel
+	env.FlagVar(environment, &level, "LEVEL")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, LogLevel(2), level)
+
+	environment = env.MakeEnvSet(func(string) (string, bool) { return "trace", true })
+	env.FlagVar(environment, &level, "LEVEL")
+
+	require.EqualError(t, environment.Parse(), "failed to parse LEVEL: unknown level: trace")
+}
+
+func TestConsolidatedRequiredErrors(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		if name == "PORT" {
+			return "not a port", true
+		}
+		return "", false
+	})
+	environment.SetRequiredErrorMode(true)
+
+	var (
+		a, b, c string
+		port    int
+	)
+
+	env.FlagVar(environment, &c, "C", env.Options[string]{Required: true})
+	env.FlagVar(environment, &a, "A", env.Options[string]{Required: true})
+	env.FlagVar(environment, &b, "B", env.Options[string]{Required: true})
+	env.FlagVar(environment, &port, "PORT")
+
+	err := environment.Parse()
+	require.ErrorContains(t, err, "the following required variables are not set: A, B, C")
+	require.ErrorContains(t, err, "failed to parse PORT")
+	require.NotContains(t, err.Error(), "is required but not found")
+}
+
+func TestMailAddress(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"FROM":       "Ops <ops@example.com>",
+			"RECIPIENTS": "Alice <alice@example.com>, bob@example.com",
+			"INVALID":    "not an address",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		from       *mail.Address
+		recipients []*mail.Address
+	)
+
+	env.FlagVar(environment, &from, "FROM")
+	env.FlagVar(environment, &recipients, "RECIPIENTS")
+
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, &mail.Address{Name: "Ops", Address: "ops@example.com"}, from)
+	require.Equal(
+		t,
+		[]*mail.Address{
+			{Name: "Alice", Address: "alice@example.com"},
+			{Name: "", Address: "bob@example.com"},
+		},
+		recipients,
+	)
+
+	var invalid mail.Address
+	env.FlagVar(environment, &invalid, "INVALID")
+
+	require.ErrorContains(t, environment.Parse(), "failed to parse INVALID: mail: ")
+}
+
+func TestValidate(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"NAME":  "bob",
+			"COUNT": "not a number",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		name     = "initial"
+		count    = 7
+		tags     = []string{"a"}
+		required string
+	)
+
+	env.FlagVar(environment, &name, "NAME")
+	env.FlagVar(environment, &count, "COUNT")
+	env.FlagVar(environment, &tags, "TAGS", env.Options[[]string]{DefaultValue: []string{"b", "c"}})
+	env.FlagVar(environment, &required, "REQUIRED", env.Options[string]{Required: true})
+
+	err := environment.Validate()
+	require.ErrorContains(t, err, "failed to parse COUNT")
+	require.ErrorContains(t, err, `"REQUIRED" is required but not found`)
+
+	require.Equal(t, "initial", name)
+	require.Equal(t, 7, count)
+	require.Equal(t, []string{"a"}, tags)
+	require.Equal(t, "", required)
+}
+
+func TestValidatePointerDestination(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"PORT": "9090", "HOST": "example.com"}[name]
+		return value, ok
+	})
+
+	var (
+		port  = 8080
+		p     = &port
+		host  = "localhost"
+		pp    = &host
+		ppp   = &pp
+		trace strings.Builder
+	)
+
+	env.FlagVar(environment, &p, "PORT")
+	env.FlagVar(environment, &ppp, "HOST")
+
+	require.NoError(t, environment.Validate())
+	require.Equal(t, 8080, port)
+	require.Equal(t, "localhost", host)
+
+	require.NoError(t, environment.Describe(&trace))
+	require.Equal(t, 8080, port)
+	require.Equal(t, "localhost", host)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 9090, port)
+	require.Equal(t, "example.com", host)
+}
+
+func TestSeparatorPrefix(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"PIPES":      "@|a,1|b,2|c",
+			"SEMICOLONS": "@;x=1;y=2",
+			"DEFAULT":    "a,b",
+		}[name]
+		return value, ok
+	})
+
+	var pipes, semicolons, fallback []string
+
+	opts := env.Options[[]string]{SeparatorPrefix: true}
+
+	env.FlagVar(environment, &pipes, "PIPES", opts)
+	env.FlagVar(environment, &semicolons, "SEMICOLONS", opts)
+	env.FlagVar(environment, &fallback, "DEFAULT", opts)
+
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, []string{"a,1", "b,2", "c"}, pipes)
+	require.Equal(t, []string{"x=1", "y=2"}, semicolons)
+	require.Equal(t, []string{"a", "b"}, fallback)
+}
+
+func TestMergeSources(t *testing.T) {
+	environment := env.MakeEnvSet(
+		env.CommandLineArgs("--labels=team=core,tier=1"),
+		func(name string) (string, bool) {
+			if name == "LABELS" {
+				return "tier=2,region=eu", true
+			}
+			return "", false
+		},
+	)
+
+	var merged, first map[string]string
+
+	env.FlagVar(environment, &merged, "LABELS", env.Options[map[string]string]{MergeSources: true})
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]string{"team": "core", "tier": "1", "region": "eu"}, merged)
+
+	env.FlagVar(environment, &first, "LABELS")
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]string{"team": "core", "tier": "1"}, first)
+}
+
+func TestMergeSourcesSeparators(t *testing.T) {
+	environment := env.MakeEnvSet(
+		func(name string) (string, bool) {
+			value, ok := map[string]string{"LABELS": "a=1;b=2", "QUERY": "a=1&b=2"}[name]
+			return value, ok
+		},
+		func(name string) (string, bool) {
+			value, ok := map[string]string{"LABELS": "c=3;a=4", "QUERY": "c=3&a=4"}[name]
+			return value, ok
+		},
+	)
+
+	var labels, query map[string]string
+
+	env.FlagVar(environment, &labels, "LABELS", env.Options[map[string]string]{MergeSources: true, Separator: ";"})
+	env.FlagVar(environment, &query, "QUERY", env.Options[map[string]string]{MergeSources: true, QueryString: true})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]string{"a": "1", "b": "2", "c": "3"}, labels)
+	require.Equal(t, map[string]string{"a": "1", "b": "2", "c": "3"}, query)
+}
+
+func TestSkipEmpty(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return ",1,,2, ,3,", true })
+
+	var numbers []int
+	env.FlagVar(environment, &numbers, "NUMBERS", env.Options[[]int]{SkipEmpty: true})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []int{1, 2, 3}, numbers)
+
+	var strs []string
+	env.FlagVar(environment, &strs, "NUMBERS")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []string{"", "1", "", "2", " ", "3", ""}, strs)
+}
+
+func TestThousandsSeparator(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"INT":   "1,000",
+			"UINT":  "1,000,000",
+			"FLOAT": "12,345.5",
+			"LIST":  "1,000",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		i    int
+		u    uint64
+		f    float64
+		list []int
+	)
+
+	env.FlagVar(environment, &i, "INT", env.Options[int]{ThousandsSeparator: ','})
+	env.FlagVar(environment, &u, "UINT", env.Options[uint64]{ThousandsSeparator: ','})
+	env.FlagVar(environment, &f, "FLOAT", env.Options[float64]{ThousandsSeparator: ','})
+	env.FlagVar(environment, &list, "LIST", env.Options[[]int]{ThousandsSeparator: ','})
+
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, 1000, i)
+	require.Equal(t, uint64(1000000), u)
+	require.Equal(t, 12345.5, f)
+	require.Equal(t, []int{1, 0}, list)
+
+	var plain int
+	env.FlagVar(environment, &plain, "INT")
+
+	require.ErrorContains(t, environment.Parse(), `failed to parse INT: strconv.ParseInt: parsing "1,000": invalid syntax`)
+}
+
+type Celsius struct {
+	Degrees float64
+}
+
+func TestRegisterDecoder(t *testing.T) {
+	env.RegisterDecoder(reflect.TypeOf(Celsius{}), func(text string, v reflect.Value) error {
+		degrees, err := strconv.ParseFloat(strings.TrimSuffix(text, "C"), 64)
+		if err != nil {
+			return err
+		}
+		v.Set(reflect.ValueOf(Celsius{Degrees: degrees}))
+		return nil
+	})
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"MAX":     "21.5C",
+			"HISTORY": "10C,-3C",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		max     Celsius
+		history []*Celsius
+	)
+
+	env.FlagVar(environment, &max, "MAX")
+	env.FlagVar(environment, &history, "HISTORY")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, Celsius{Degrees: 21.5}, max)
+	require.Equal(t, []*Celsius{{Degrees: 10}, {Degrees: -3}}, history)
+}
+
+func TestOptionalBool(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		Lookup   env.LookupFunc
+		Expected *bool
+	}{
+		{
+			Name:     "unset",
+			Lookup:   func(string) (string, bool) { return "", false },
+			Expected: nil,
+		},
+		{
+			Name:     "true",
+			Lookup:   func(string) (string, bool) { return "true", true },
+			Expected: func() *bool { value := true; return &value }(),
+		},
+		{
+			Name:     "false",
+			Lookup:   func(string) (string, bool) { return "false", true },
+			Expected: func() *bool { value := false; return &value }(),
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			environment := env.MakeEnvSet(tc.Lookup)
+
+			var enabled *bool
+			env.FlagVar(environment, &enabled, "ENABLED")
+
+			require.NoError(t, environment.Parse())
+			require.Equal(t, tc.Expected, enabled)
+		})
+	}
+}
+
+func TestBytesFromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cert.der")
+	require.NoError(t, os.WriteFile(path, []byte{0x30, 0x82, 0x00, 0xff}, 0o600))
+
+	environment := env.MakeEnvSet(func(string) (string, bool) { return path, true })
+
+	var cert []byte
+	env.FlagVar(environment, &cert, "CERT", env.Options[[]byte]{FromFile: true})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []byte{0x30, 0x82, 0x00, 0xff}, cert)
+
+	environment = env.MakeEnvSet(func(string) (string, bool) { return filepath.Join(t.TempDir(), "missing.der"), true })
+	env.FlagVar(environment, &cert, "CERT", env.Options[[]byte]{FromFile: true})
+
+	require.ErrorContains(t, environment.Parse(), "failed to parse CERT: open ")
+}
+
+func TestFallbackOnError(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "many", true })
+
+	var workers, strict int
+	env.FlagVar(environment, &workers, "WORKERS", env.Options[int]{DefaultValue: 4, FallbackOnError: true})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 4, workers)
+	require.Equal(
+		t,
+		[]string{`failed to parse WORKERS: strconv.ParseInt: parsing "many": invalid syntax: using default value`},
+		environment.Warnings(),
+	)
+
+	env.FlagVar(environment, &strict, "STRICT", env.Options[int]{DefaultValue: 4})
+
+	require.ErrorContains(t, environment.Parse(), "failed to parse STRICT")
+}
+
+func TestOnParse(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"PORT":     "8080",
+			"COUNT":    "many",
+			"PASSWORD": "hunter2",
+		}[name]
+		return value, ok
+	})
+
+	type event struct {
+		Name string
+		Raw  string
+		Err  string
+	}
+
+	var events []event
+	environment.OnParse(func(name, raw string, err error) {
+		e := event{Name: name, Raw: raw}
+		if err != nil {
+			e.Err = err.Error()
+		}
+		events = append(events, e)
+	})
+
+	var (
+		port     int
+		count    int
+		password string
+		host     string
+	)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &count, "COUNT")
+	env.FlagVar(environment, &password, "PASSWORD", env.Options[string]{Secret: true})
+	env.FlagVar(environment, &host, "HOST", env.Options[string]{Required: true})
+
+	require.Error(t, environment.Parse())
+	require.ElementsMatch(
+		t,
+		[]event{
+			{Name: "PORT", Raw: "8080"},
+			{Name: "COUNT", Raw: "many", Err: `failed to parse COUNT: strconv.ParseInt: parsing "many": invalid syntax`},
+			{Name: "PASSWORD", Raw: "[redacted]"},
+			{Name: "HOST", Err: `"HOST" is required but not found`},
+		},
+		events,
+	)
+}
+
+type Port uint16
+
+func TestNamedIntegerOverflow(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"PORT":     "8080",
+			"OVERFLOW": "70000",
+		}[name]
+		return value, ok
+	})
+
+	var port, overflow Port
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &overflow, "OVERFLOW")
+
+	require.EqualError(t, environment.Parse(), `failed to parse OVERFLOW: strconv.ParseUint: parsing "70000": value out of range`)
+	require.Equal(t, Port(8080), port)
+}
+
+func TestRequireAllOrNone(t *testing.T) {
+	lookup := func(vars map[string]string) env.LookupFunc {
+		return func(name string) (string, bool) {
+			value, ok := vars[name]
+			return value, ok
+		}
+	}
+
+	testCases := []struct {
+		Name  string
+		Vars  map[string]string
+		Error string
+	}{
+		{
+			Name: "none",
+			Vars: map[string]string{},
+		},
+		{
+			Name: "all",
+			Vars: map[string]string{"AWS_KEY": "key", "AWS_SECRET": "secret", "AWS_REGION": "eu-west-1"},
+		},
+		{
+			Name:  "partial",
+			Vars:  map[string]string{"AWS_KEY": "key"},
+			Error: "variables must be set together: present: AWS_KEY; missing: AWS_SECRET, AWS_REGION",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			environment := env.MakeEnvSet(lookup(tc.Vars))
+			environment.RequireAllOrNone("AWS_KEY", "AWS_SECRET", "AWS_REGION")
+
+			var key, secret string
+			env.FlagVar(environment, &key, "AWS_KEY")
+			env.FlagVar(environment, &secret, "AWS_SECRET")
+
+			err := environment.Parse()
+			if tc.Error == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.Error)
+		})
+	}
+}
+
+func TestMutuallyExclusive(t *testing.T) {
+	lookup := func(vars map[string]string) env.LookupFunc {
+		return func(name string) (string, bool) {
+			value, ok := vars[name]
+			return value, ok
+		}
+	}
+
+	testCases := []struct {
+		Name  string
+		Vars  map[string]string
+		Error string
+	}{
+		{
+			Name: "none",
+			Vars: map[string]string{},
+		},
+		{
+			Name: "one",
+			Vars: map[string]string{"YAML": "true"},
+		},
+		{
+			Name:  "conflicting",
+			Vars:  map[string]string{"JSON": "true", "YAML": "false"},
+			Error: "variables are mutually exclusive: present: JSON, YAML",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			environment := env.MakeEnvSet(lookup(tc.Vars))
+			environment.MutuallyExclusive("JSON", "YAML", "TEXT")
+
+			var json, yaml, text bool
+			env.FlagVar(environment, &json, "JSON")
+			env.FlagVar(environment, &yaml, "YAML")
+			env.FlagVar(environment, &text, "TEXT")
+
+			err := environment.Parse()
+			if tc.Error == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.Error)
+		})
+	}
+}
+
+func TestValuePreprocessor(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"NAME":  `"bob"`,
+			"COUNT": `"42"`,
+			"RAW":   `plain`,
+		}[name]
+		return value, ok
+	})
+
+	environment.SetValuePreprocessor(func(name, raw string) string {
+		if unquoted, err := strconv.Unquote(raw); err == nil {
+			return unquoted
+		}
+		return raw
+	})
+
+	var (
+		name  string
+		count int
+		raw   string
+	)
+
+	env.FlagVar(environment, &name, "NAME")
+	env.FlagVar(environment, &count, "COUNT")
+	env.FlagVar(environment, &raw, "RAW")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "bob", name)
+	require.Equal(t, 42, count)
+	require.Equal(t, "plain", raw)
+}
+
+func TestEnvSetCommandLineArgs(t *testing.T) {
+	environment := env.MakeEnvSet()
+	environment.SetLookupFunc(environment.CommandLineArgs("--port", "1", "--port", "2", "--host=a", "--host=b", "--name", "x,y"))
+
+	var (
+		port  int
+		hosts []string
+		name  string
+	)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &hosts, "HOST")
+	env.FlagVar(environment, &name, "NAME")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 2, port)
+	require.Equal(t, []string{"a", "b"}, hosts)
+	require.Equal(t, "x,y", name)
+
+	joined, ok := env.CommandLineArgs("--port", "1", "--port", "2")("PORT")
+	require.True(t, ok)
+	require.Equal(t, "1,2", joined)
+}
+
+func TestUnusedEnv(t *testing.T) {
+	t.Setenv("APP_DATABASE_URL", "postgres://localhost")
+	t.Setenv("APP_DATABSE_URL", "postgres://typo")
+	t.Setenv("APP_PORT", "8080")
+	t.Setenv("OTHER_PORT", "9090")
+
+	environment := env.MakeEnvSet()
+
+	var databaseURL, port string
+	env.FlagVar(environment, &port, "APP_PORT")
+	env.FlagVar(environment, &databaseURL, "APP_DATABASE_URL")
+
+	require.Equal(t, []string{"APP_DATABASE_URL", "APP_PORT"}, environment.Names())
+	require.Equal(t, []string{"APP_DATABSE_URL"}, environment.UnusedEnv("APP_"))
+
+	t.Setenv("APP_FEATURE_X", "on")
+
+	var features map[string]string
+	env.VarPrefix(environment, &features, "APP_FEATURE_")
+
+	require.Equal(t, []string{"APP_DATABSE_URL"}, environment.UnusedEnv("APP_"))
+}
+
+func TestPresenceIsTrue(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"VERBOSE": "",
+			"DEBUG":   "false",
+		}[name]
+		return value, ok
+	})
+
+	var verbose, debug, quiet bool
+	opts := env.Options[bool]{PresenceIsTrue: true}
+
+	env.FlagVar(environment, &verbose, "VERBOSE", opts)
+	env.FlagVar(environment, &debug, "DEBUG", opts)
+	env.FlagVar(environment, &quiet, "QUIET", opts)
+
+	require.NoError(t, environment.Parse())
+	require.True(t, verbose)
+	require.False(t, debug)
+	require.False(t, quiet)
+
+	env.FlagVar(environment, &verbose, "VERBOSE")
+
+	require.EqualError(t, environment.Parse(), `failed to parse VERBOSE: strconv.ParseBool: parsing "": invalid syntax`)
+}
+
+func TestUUID(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"ID":      "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			"IDS":     "6ba7b810-9dad-11d1-80b4-00c04fd430c8,6ba7b811-9dad-11d1-80b4-00c04fd430c8",
+			"INVALID": "6ba7b810,not-a-uuid",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		id  uuid.UUID
+		ids []uuid.UUID
+	)
+
+	env.FlagVar(environment, &id, "ID")
+	env.FlagVar(environment, &ids, "IDS")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"), id)
+	require.Equal(
+		t,
+		[]uuid.UUID{
+			uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"),
+			uuid.MustParse("6ba7b811-9dad-11d1-80b4-00c04fd430c8"),
+		},
+		ids,
+	)
+
+	var invalid []uuid.UUID
+	env.FlagVar(environment, &invalid, "INVALID")
+
+	require.ErrorContains(t, environment.Parse(), "failed to parse INVALID: invalid UUID length: 8")
+}
+
+func TestVarUUID(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"ID":      "urn:uuid:6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+			"INVALID": "not-a-uuid",
+		}[name]
+		return value, ok
+	})
+
+	var id, fallback, invalid uuid.UUID
+
+	env.VarUUID(environment, &id, "ID")
+	env.VarUUID(environment, &fallback, "FALLBACK", env.Options[uuid.UUID]{DefaultValue: uuid.Max})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, uuid.MustParse("6ba7b810-9dad-11d1-80b4-00c04fd430c8"), id)
+	require.Equal(t, uuid.Max, fallback)
+
+	env.VarUUID(environment, &invalid, "INVALID")
+	require.EqualError(t, environment.Parse(), "failed to parse INVALID: invalid UUID length: 10")
+}
+
+func TestPointerToUnmarshaler(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) { return "value", true })
+
+	var (
+		text  *CapText
+		texts map[string]CapText
+	)
+
+	env.FlagVar(environment, &text, "TEXT")
+	require.NoError(t, environment.Parse())
+	require.Equal(t, CapText("VALUE"), *text)
+
+	environment = env.MakeEnvSet(func(name string) (string, bool) { return "a=x,b=y", true })
+	env.FlagVar(environment, &texts, "TEXTS")
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]CapText{"a": "X", "b": "Y"}, texts)
+}
+
+func TestEmptySlice(t *testing.T) {
+	testCases := []struct {
+		Input    string
+		Expected []int
+		Error    string
+	}{
+		{Input: "", Expected: []int{}},
+		{Input: " ", Expected: []int{}},
+		{Input: "1", Expected: []int{1}},
+		{Input: "a", Error: `failed to parse NUMBERS: strconv.ParseInt: parsing "a": invalid syntax`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(strconv.Quote(tc.Input), func(t *testing.T) {
+			environment := env.MakeEnvSet(func(string) (string, bool) { return tc.Input, true })
+
+			var numbers []int
+			env.FlagVar(environment, &numbers, "NUMBERS")
+
+			err := environment.Parse()
+			if tc.Error != "" {
+				require.EqualError(t, err, tc.Error)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.Expected, numbers)
+		})
+	}
+}
+
+func TestQueryStringMap(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"PARAMS": "a=1&b=hello%20world&c=x%2Cy&a=2",
+			"MULTI":  "tag=a&tag=b&id=1",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		params map[string]string
+		multi  map[string][]string
+	)
+
+	env.FlagVar(environment, &params, "PARAMS", env.Options[map[string]string]{QueryString: true})
+	env.FlagVar(environment, &multi, "MULTI", env.Options[map[string][]string]{QueryString: true})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]string{"a": "1", "b": "hello world", "c": "x,y"}, params)
+	require.Equal(t, map[string][]string{"tag": {"a", "b"}, "id": {"1"}}, multi)
+}
+
+func TestParseAll(t *testing.T) {
+	payments := env.MakeEnvSet(func(string) (string, bool) { return "", false })
+	users := env.MakeEnvSet(func(string) (string, bool) { return "8080", true })
+
+	var (
+		databaseURL string
+		port        int
+	)
+
+	env.FlagVar(payments, &databaseURL, "DB_URL", env.Options[string]{Required: true})
+	env.FlagVar(users, &port, "PORT")
+
+	require.EqualError(t, env.ParseAll(payments, users), `"DB_URL" is required but not found`)
+	require.Equal(t, 8080, port)
+
+	require.NoError(t, env.ParseAll(users))
+}
+
+func TestSetName(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		if name == "RETRIES" {
+			return "many", true
+		}
+		return "", false
+	})
+	environment.SetName("payments")
+
+	var (
+		databaseURL string
+		retries     int
+	)
+
+	env.FlagVar(environment, &databaseURL, "DB_URL", env.Options[string]{Required: true})
+	env.FlagVar(environment, &retries, "RETRIES", env.Options[int]{DefaultValue: 3, FallbackOnError: true})
+
+	require.EqualError(t, env.ParseAll(environment), `[payments] "DB_URL" is required but not found`)
+	require.Equal(
+		t,
+		[]string{`[payments] failed to parse RETRIES: strconv.ParseInt: parsing "many": invalid syntax: using default value`},
+		environment.Warnings(),
+	)
+}
+
+func TestNestedUnmarshalers(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"DOCUMENTS": `{"a":1,"b":[1,2]},[3,4],"x,y"`,
+			"MATRIX":    `[[1,2],{"k":"v"}],[3]`,
+		}[name]
+		return value, ok
+	})
+
+	var (
+		documents []Custom
+		matrix    [][]Custom
+	)
+
+	opts := env.Options[[]Custom]{NestedUnmarshalers: true}
+
+	env.FlagVar(environment, &documents, "DOCUMENTS", opts)
+	env.FlagVar(environment, &matrix, "MATRIX", env.Options[[][]Custom]{NestedUnmarshalers: true})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(
+		t,
+		[]Custom{
+			{Value: map[string]any{"a": 1.0, "b": []any{1.0, 2.0}}},
+			{Value: []any{3.0, 4.0}},
+			{Value: "x,y"},
+		},
+		documents,
+	)
+	require.Equal(
+		t,
+		[][]Custom{
+			{{Value: []any{1.0, 2.0}}, {Value: map[string]any{"k": "v"}}},
+			{{Value: 3.0}},
+		},
+		matrix,
+	)
+
+	env.FlagVar(environment, &matrix, "MATRIX")
+	require.EqualError(t, environment.Parse(), "failed to parse MATRIX: cannot support deep slices")
+}
+
+func TestGroup(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"REDIS_HOST":         "localhost",
+			"REDIS_PORT":         "6379",
+			"REDIS_REPLICA_HOST": "replica",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		host        string
+		port        int
+		replicaHost string
+	)
+
+	redis := environment.Group("REDIS")
+	env.FlagVar(redis, &host, "HOST")
+	env.FlagVar(redis, &port, "PORT")
+	env.FlagVar(redis.Group("REPLICA"), &replicaHost, "HOST")
+
+	require.Equal(t, []string{"REDIS_HOST", "REDIS_PORT", "REDIS_REPLICA_HOST"}, environment.Names())
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "localhost", host)
+	require.Equal(t, 6379, port)
+	require.Equal(t, "replica", replicaHost)
+}
+
+func TestVarChoice(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"HASH":    "sha256",
+			"INVALID": "md5",
+		}[name]
+		return value, ok
+	})
+
+	choices := map[string]func(string) string{
+		"sha256": func(s string) string { return "sha256:" + s },
+		"sha512": func(s string) string { return "sha512:" + s },
+	}
+
+	var hash func(string) string
+	env.VarChoice(environment, &hash, "HASH", choices)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "sha256:data", hash("data"))
+
+	var invalid func(string) string
+	env.VarChoice(environment, &invalid, "INVALID", choices)
+
+	require.EqualError(t, environment.Parse(), `failed to parse INVALID: expected one of ["sha256" "sha512"] but got "md5"`)
+}
+
+func TestWithCaseInsensitiveEnv(t *testing.T) {
+	t.Setenv("DATABASE_URL", "postgres://localhost")
+	t.Setenv("log_level", "debug")
+
+	environment := env.MakeEnvSet(env.WithCaseInsensitiveEnv())
+
+	var (
+		url   string
+		level string
+	)
+
+	env.FlagVar(environment, &url, "database_url")
+	env.FlagVar(environment, &level, "LOG_LEVEL")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "postgres://localhost", url)
+	require.Equal(t, "debug", level)
+}
+
+func TestEnvSetCommandLineArgsBool(t *testing.T) {
+	environment := env.MakeEnvSet()
+	environment.SetLookupFunc(environment.CommandLineArgs("-force", "somefile", "--output", "out.txt", "--dry-run=false", "--verbose"))
+
+	var (
+		force   bool
+		dryRun  bool
+		verbose bool
+		output  string
+	)
+
+	env.FlagVar(environment, &force, "FORCE")
+	env.FlagVar(environment, &dryRun, "DRY_RUN", env.Options[bool]{DefaultValue: true})
+	env.FlagVar(environment, &verbose, "VERBOSE")
+	env.FlagVar(environment, &output, "OUTPUT")
+
+	require.NoError(t, environment.Parse())
+	require.True(t, force)
+	require.False(t, dryRun)
+	require.True(t, verbose)
+	require.Equal(t, "out.txt", output)
+
+	// Without knowledge of the variables the positional is taken as the value of the flag.
+	value, ok := env.CommandLineArgs("-force", "somefile")("FORCE")
+	require.True(t, ok)
+	require.Equal(t, "somefile", value)
+}
+
+func TestParsePartial(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"HOST":     "localhost",
+			"PORT":     "not-a-number",
+			"TAGS":     "a,b",
+			"PASSWORD": "hunter2",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		host     string
+		port     int
+		tags     []string
+		timeout  time.Duration
+		password string
+		token    string
+	)
+
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &tags, "TAGS")
+	env.FlagVar(environment, &timeout, "TIMEOUT", env.Options[time.Duration]{DefaultValue: 5 * time.Second})
+	env.FlagVar(environment, &password, "PASSWORD", env.Options[string]{Secret: true})
+	env.FlagVar(environment, &token, "TOKEN", env.Options[string]{Required: true})
+
+	resolved, err := environment.ParsePartial()
+	require.Error(t, err)
+	require.ErrorContains(t, err, "failed to parse PORT")
+	require.ErrorContains(t, err, `"TOKEN" is required but not found`)
+
+	require.Equal(
+		t,
+		map[string]string{
+			"HOST":     "localhost",
+			"TAGS":     "a,b",
+			"TIMEOUT":  "5s",
+			"PASSWORD": "[redacted]",
+		},
+		resolved,
+	)
+	require.Equal(t, "localhost", host)
+	require.Equal(t, 5*time.Second, timeout)
+}
+
+func TestAutoDecode(t *testing.T) {
+	random := make([]byte, 48)
+	_, err := rand.Read(random)
+	require.NoError(t, err)
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"KEY":      base64.StdEncoding.EncodeToString([]byte{0xde, 0xad, 0xbe, 0xef}),
+			"PASSWORD": base64.StdEncoding.EncodeToString([]byte("correct horse")),
+			"RAW":      "test",
+			"INVALID":  "not base64!",
+			"PATH":     "/tmp/key",
+			"WORD":     "abcd",
+			"PRINTED":  base64.StdEncoding.EncodeToString([]byte("hello world!")),
+			"RANDOM":   base64.StdEncoding.EncodeToString(random),
+			"LONGPATH": "/var/lib/application/secrets/key",
+		}[name]
+		return value, ok
+	})
+
+	var key, password, raw, invalid, path, word, printed, randomKey, longPath []byte
+
+	opts := env.Options[[]byte]{AutoDecode: true}
+
+	env.FlagVar(environment, &key, "KEY", opts)
+	env.FlagVar(environment, &password, "PASSWORD", opts)
+	env.FlagVar(environment, &raw, "RAW", opts)
+	env.FlagVar(environment, &invalid, "INVALID", opts)
+	env.FlagVar(environment, &path, "PATH", opts)
+	env.FlagVar(environment, &word, "WORD", opts)
+	env.FlagVar(environment, &printed, "PRINTED", opts)
+	env.FlagVar(environment, &randomKey, "RANDOM", opts)
+	env.FlagVar(environment, &longPath, "LONGPATH", opts)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []byte{0xde, 0xad, 0xbe, 0xef}, key)
+	require.Equal(t, []byte("correct horse"), password)
+	require.Equal(t, []byte("test"), raw)
+	require.Equal(t, []byte("not base64!"), invalid)
+	require.Equal(t, []byte("/tmp/key"), path)
+	require.Equal(t, []byte("abcd"), word)
+	require.Equal(t, []byte("hello world!"), printed)
+	require.Equal(t, random, randomKey)
+	require.Equal(t, []byte("/var/lib/application/secrets/key"), longPath)
+}
+
+func TestItemBounds(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"BROKERS": "",
+			"TOPICS":  "a,b,c,d",
+			"HOSTS":   "a,b",
+		}[name]
+		return value, ok
+	})
+
+	var brokers, topics, hosts []string
+
+	env.FlagVar(environment, &brokers, "BROKERS", env.Options[[]string]{MinItems: 1})
+	env.FlagVar(environment, &topics, "TOPICS", env.Options[[]string]{MaxItems: 3})
+	env.FlagVar(environment, &hosts, "HOSTS", env.Options[[]string]{MinItems: 1, MaxItems: 3})
+
+	err := environment.Parse()
+	require.ErrorContains(t, err, "invalid value for BROKERS: expected at least 1 items but got 0")
+	require.ErrorContains(t, err, "invalid value for TOPICS: expected at most 3 items but got 4")
+	require.NotContains(t, err.Error(), "HOSTS")
+	require.Equal(t, []string{"a", "b"}, hosts)
+}
+
+type reverseDecryptor struct{}
+
+func (reverseDecryptor) Decrypt(ctx context.Context, ciphertext string) (string, error) {
+	if ciphertext == "" {
+		return "", errors.New("empty ciphertext")
+	}
+	runes := []rune(ciphertext)
+	for i, j := 0, len(runes)-1; i < j; i, j = i+1, j-1 {
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes), nil
+}
+
+func TestDecryptor(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"PASSWORD": "enc:2retnuh",
+			"USER":     "admin",
+			"TOKEN":    "enc:",
+		}[name]
+		return value, ok
+	})
+	environment.WithDecryptor(reverseDecryptor{})
+
+	var password, user string
+
+	env.FlagVar(environment, &password, "PASSWORD")
+	env.FlagVar(environment, &user, "USER")
+
+	require.NoError(t, environment.ParseContext(context.Background()))
+	require.Equal(t, "hunter2", password)
+	require.Equal(t, "admin", user)
+
+	var token string
+	env.FlagVar(environment, &token, "TOKEN")
+
+	require.EqualError(t, environment.Parse(), "failed to decrypt TOKEN: empty ciphertext")
+}
+
+func TestPerVariableLookup(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"HOST":     "localhost",
+			"PASSWORD": "from-env",
+			"USER":     "admin",
+		}[name]
+		return value, ok
+	})
+
+	secrets := func(name string) (string, bool) {
+		value, ok := map[string]string{"PASSWORD": "from-file"}[name]
+		return value, ok
+	}
+
+	var host, password, user string
+
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &password, "PASSWORD", env.Options[string]{Lookup: secrets})
+	env.FlagVar(environment, &user, "USER", env.Options[string]{Lookup: secrets})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "localhost", host)
+	require.Equal(t, "from-file", password)
+	require.Equal(t, "admin", user)
+}
+
+func TestParseTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		if name == "SLOW" {
+			<-release
+		}
+		return "value", true
+	})
+
+	var fast, slow string
+
+	env.FlagVar(environment, &fast, "FAST")
+	env.FlagVar(environment, &slow, "SLOW")
+
+	err := environment.ParseTimeout(50 * time.Millisecond)
+	require.EqualError(t, err, "timed out resolving SLOW: context deadline exceeded")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Equal(t, "", slow)
+
+	environment = env.MakeEnvSet(func(string) (string, bool) { return "value", true })
+	env.FlagVar(environment, &fast, "FAST")
+
+	require.NoError(t, environment.ParseTimeout(time.Second))
+	require.Equal(t, "value", fast)
+}
+
+func TestBigRat(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"FRACTION": "3/7",
+			"DECIMAL":  "0.5",
+			"INVALID":  "three sevenths",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		fraction big.Rat
+		decimal  *big.Rat
+	)
+
+	env.FlagVar(environment, &fraction, "FRACTION")
+	env.FlagVar(environment, &decimal, "DECIMAL")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "3/7", fraction.String())
+	require.Equal(t, "1/2", decimal.String())
+
+	var invalid big.Rat
+	env.FlagVar(environment, &invalid, "INVALID")
+
+	require.EqualError(t, environment.Parse(), `failed to parse INVALID: math/big: cannot unmarshal "three sevenths" into a *big.Rat`)
+}
+
+func TestSetDefaultLookup(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"HOST": "example.com"}[name]
+		return value, ok
+	})
+	environment.SetDefaultLookup(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"HOST": "localhost",
+			"PORT": "8080",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		host    string
+		port    int
+		timeout time.Duration
+	)
+
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &port, "PORT", env.Options[int]{DefaultValue: 80, Required: true})
+	env.FlagVar(environment, &timeout, "TIMEOUT", env.Options[time.Duration]{DefaultValue: time.Second})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "example.com", host)
+	require.Equal(t, 8080, port)
+	require.Equal(t, time.Second, timeout)
+}
+
+func TestVarPrefix(t *testing.T) {
+	vars := map[string]string{
+		"FEATURE_X":     "on",
+		"FEATURE_Y":     "off",
+		"FEATURE_":      "ignored",
+		"OTHER_FEATURE": "ignored",
+	}
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	})
+
+	var features map[string]string
+	env.VarPrefix(environment, &features, "FEATURE_")
+
+	require.EqualError(t, environment.Parse(), "cannot collect variables with prefix FEATURE_: the lookup cannot be enumerated")
+
+	environment.SetKeysFunc(func() []string {
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		return keys
+	})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]string{"X": "on", "Y": "off"}, features)
+
+	t.Setenv("APP_FLAG_BETA", "true")
+
+	var flags map[string]string
+	environment = env.MakeEnvSet()
+	env.VarPrefix(environment, &flags, "APP_FLAG_")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]string{"BETA": "true"}, flags)
+
+	environment.PushOverride(func(name string) (string, bool) { return "false", name == "APP_FLAG_BETA" })
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]string{"BETA": "false"}, flags)
+}
+
+func TestVarPrefixTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		if name == "FEATURE_SLOW" {
+			<-release
+		}
+		return "on", true
+	})
+	environment.SetKeysFunc(func() []string { return []string{"FEATURE_SLOW"} })
+
+	var features map[string]string
+	env.VarPrefix(environment, &features, "FEATURE_")
+
+	err := environment.ParseTimeout(50 * time.Millisecond)
+	require.EqualError(t, err, "timed out resolving FEATURE_SLOW: context deadline exceeded")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.Nil(t, features)
+}
+
+func TestWeekdayAndMonth(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"START_DAY":   "Monday",
+			"END_DAY":     "5",
+			"REST_DAYS":   "saturday,SUNDAY",
+			"START_MONTH": "march",
+			"END_MONTH":   "12",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		startDay, endDay     time.Weekday
+		restDays             []time.Weekday
+		startMonth, endMonth time.Month
+	)
+
+	env.FlagVar(environment, &startDay, "START_DAY")
+	env.FlagVar(environment, &endDay, "END_DAY")
+	env.FlagVar(environment, &restDays, "REST_DAYS")
+	env.FlagVar(environment, &startMonth, "START_MONTH")
+	env.FlagVar(environment, &endMonth, "END_MONTH")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, time.Monday, startDay)
+	require.Equal(t, time.Friday, endDay)
+	require.Equal(t, []time.Weekday{time.Saturday, time.Sunday}, restDays)
+	require.Equal(t, time.March, startMonth)
+	require.Equal(t, time.December, endMonth)
+
+	for _, tc := range []struct {
+		Value string
+		Error string
+	}{
+		{Value: "Funday", Error: `failed to parse DAY: invalid weekday "Funday"`},
+		{Value: "7", Error: `failed to parse DAY: invalid weekday "7"`},
+	} {
+		environment := env.MakeEnvSet(func(string) (string, bool) { return tc.Value, true })
+
+		var day time.Weekday
+		env.FlagVar(environment, &day, "DAY")
+
+		require.EqualError(t, environment.Parse(), tc.Error)
+	}
+
+	environment = env.MakeEnvSet(func(string) (string, bool) { return "0", true })
+
+	var month time.Month
+	env.FlagVar(environment, &month, "MONTH")
+
+	require.EqualError(t, environment.Parse(), `failed to parse MONTH: invalid month "0"`)
+}
+
+func TestWithMetrics(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		if name == "SLOW" {
+			time.Sleep(10 * time.Millisecond)
+		}
+		value, ok := map[string]string{
+			"SLOW": "value",
+			"PORT": "invalid",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		slow, missing string
+		port          int
+	)
+
+	env.FlagVar(environment, &slow, "SLOW")
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &missing, "MISSING")
+
+	durations := map[string]time.Duration{}
+	errs := map[string]error{}
+
+	environment.WithMetrics(func(name string, dur time.Duration, err error) {
+		durations[name] = dur
+		errs[name] = err
+	})
+
+	require.Error(t, environment.Parse())
+
+	require.Len(t, durations, 3)
+	require.GreaterOrEqual(t, durations["SLOW"], 10*time.Millisecond)
+
+	require.NoError(t, errs["SLOW"])
+	require.NoError(t, errs["MISSING"])
+	require.ErrorContains(t, errs["PORT"], "failed to parse PORT")
+}
+
+func TestFromFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	leaf := filepath.Join(dir, "leaf.pem")
+	require.NoError(t, os.WriteFile(leaf, []byte("-----BEGIN CERTIFICATE-----\nleaf\n-----END CERTIFICATE-----\n"), 0o644))
+
+	intermediate := filepath.Join(dir, "intermediate.pem")
+	require.NoError(t, os.WriteFile(intermediate, []byte("-----BEGIN CERTIFICATE-----\nintermediate\n-----END CERTIFICATE-----\n"), 0o644))
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"OVERRIDE": "from-env"}[name]
+		return value, ok
+	})
+
+	var chain, override []byte
+
+	env.FlagVar(environment, &chain, "CHAIN", env.Options[[]byte]{FromFiles: []string{leaf, intermediate}})
+	env.FlagVar(environment, &override, "OVERRIDE", env.Options[[]byte]{FromFiles: []string{leaf}})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(
+		t,
+		"-----BEGIN CERTIFICATE-----\nleaf\n-----END CERTIFICATE-----\n-----BEGIN CERTIFICATE-----\nintermediate\n-----END CERTIFICATE-----\n",
+		string(chain),
+	)
+	require.Equal(t, "from-env", string(override))
+
+	missing := filepath.Join(dir, "missing.pem")
+	env.FlagVar(environment, &chain, "CHAIN", env.Options[[]byte]{FromFiles: []string{leaf, missing}})
+
+	require.EqualError(t, environment.Parse(), fmt.Sprintf("failed to read CHAIN: open %s: no such file or directory", missing))
+}
+
+func TestVarIndexed(t *testing.T) {
+	vars := map[string]string{
+		"SERVERS[0]": "a",
+		"SERVERS[2]": "c",
+		"PORTS[1]":   "8080",
+		"SERVERS":    "ignored",
+	}
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	})
+	environment.SetKeysFunc(func() []string {
+		keys := make([]string, 0, len(vars))
+		for key := range vars {
+			keys = append(keys, key)
+		}
+		return keys
+	})
+
+	var (
+		servers []string
+		ports   []int
+		missing []string
+	)
+
+	env.VarIndexed(environment, &servers, "SERVERS")
+	env.VarIndexed(environment, &ports, "PORTS")
+	env.VarIndexed(environment, &missing, "MISSING")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []string{"a", "", "c"}, servers)
+	require.Equal(t, []int{0, 8080}, ports)
+	require.Nil(t, missing)
+
+	vars["PORTS[x]"] = "1"
+	require.EqualError(t, environment.Parse(), "invalid index in PORTS[x]")
+
+	delete(vars, "PORTS[x]")
+	vars["PORTS[3]"] = "http"
+	require.EqualError(t, environment.Parse(), `failed to parse PORTS[3]: strconv.ParseInt: parsing "http": invalid syntax`)
+}
+
+func TestFreeze(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "8080", true })
+
+	var port, other int
+	env.FlagVar(environment, &port, "PORT")
+
+	environment.Freeze()
+
+	require.PanicsWithValue(t, "env: cannot register OTHER: the EnvSet is frozen", func() {
+		env.FlagVar(environment, &other, "OTHER")
+	})
+	require.PanicsWithValue(t, "env: cannot register APP_OTHER: the EnvSet is frozen", func() {
+		env.FlagVar(environment.Group("APP"), &other, "OTHER")
+	})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 8080, port)
+	require.Equal(t, []string{"PORT"}, environment.Names())
+}
+
+func TestCommandLineArgsWithPrefix(t *testing.T) {
+	environment := env.MakeEnvSet(env.CommandLineArgsWithPrefix("app-", "--app-port", "8080", "--APP-HOST=localhost", "--name", "other", "--app-database-url", "db"))
+
+	var (
+		port        int
+		host        string
+		name        string
+		databaseURL string
+	)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &name, "NAME")
+	env.FlagVar(environment, &databaseURL, "DATABASE_URL")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 8080, port)
+	require.Equal(t, "localhost", host)
+	require.Equal(t, "", name)
+	require.Equal(t, "db", databaseURL)
+
+	value, ok := env.CommandLineArgsWithPrefix("APP_", "--app-port=9090")("PORT")
+	require.True(t, ok)
+	require.Equal(t, "9090", value)
+}
+
+func TestAbsentCollections(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "", false })
+
+	var (
+		hosts    []string
+		limits   map[string]int
+		fallback error
+		tags     []string
+		labels   map[string]int
+	)
+
+	env.FlagVar(environment, &hosts, "HOSTS")
+	env.FlagVar(environment, &limits, "LIMITS", env.Options[map[string]int]{})
+	env.FlagVar(environment, &fallback, "FALLBACK", env.Options[error]{})
+	env.FlagVar(environment, &tags, "TAGS", env.Options[[]string]{EmptyCollection: true})
+	env.FlagVar(environment, &labels, "LABELS", env.Options[map[string]int]{EmptyCollection: true})
+
+	require.NoError(t, environment.Parse())
+	require.Nil(t, hosts)
+	require.Nil(t, limits)
+	require.Nil(t, fallback)
+	require.NotNil(t, tags)
+	require.Empty(t, tags)
+	require.NotNil(t, labels)
+	require.Empty(t, labels)
+}
+
+func TestDescribe(t *testing.T) {
+	environment := env.MakeEnvSet(
+		func(name string) (string, bool) {
+			value, ok := map[string]string{"PORT": "9090"}[name]
+			return value, ok
+		},
+		func(name string) (string, bool) {
+			value, ok := map[string]string{
+				"HOST":     "localhost",
+				"PASSWORD": "hunter2",
+				"RETRIES":  "many",
+			}[name]
+			return value, ok
+		},
+	)
+
+	var (
+		host     string
+		port     int
+		password string
+		timeout  time.Duration
+		retries  int
+	)
+
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &port, "PORT", env.Options[int]{DefaultValue: 8080})
+	env.FlagVar(environment, &password, "PASSWORD", env.Options[string]{Secret: true})
+	env.FlagVar(environment, &timeout, "TIMEOUT", env.Options[time.Duration]{DefaultValue: 5 * time.Second})
+	env.FlagVar(environment, &retries, "RETRIES")
+
+	var output strings.Builder
+	err := environment.Describe(&output)
+	require.EqualError(t, err, `failed to parse RETRIES: strconv.ParseInt: parsing "many": invalid syntax`)
+
+	expected := strings.Join([]string{
+		"VARIABLE  VALUE       SOURCE    DEFAULTED",
+		"HOST      localhost   lookup 2  no",
+		"PASSWORD  [redacted]  lookup 2  no",
+		"PORT      9090        lookup 1  no",
+		"RETRIES   <error>     lookup 2  no",
+		"TIMEOUT   5s          -         yes",
+		"",
+	}, "\n")
+
+	require.Equal(t, expected, output.String())
+
+	// Describe does not modify the destinations.
+	require.Equal(t, "", host)
+	require.Equal(t, 0, port)
+}
+
+func TestFileMode(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"FILE_MODE": "0644",
+			"DIR_MODE":  "0o755",
+			"KEY_MODE":  "600",
+			"SYMBOLIC":  "rwxr-x---",
+		}[name]
+		return value, ok
+	})
+
+	var fileMode, dirMode, keyMode, symbolic os.FileMode
+
+	env.FlagVar(environment, &fileMode, "FILE_MODE")
+	env.FlagVar(environment, &dirMode, "DIR_MODE")
+	env.FlagVar(environment, &keyMode, "KEY_MODE")
+	env.FlagVar(environment, &symbolic, "SYMBOLIC")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, os.FileMode(0o644), fileMode)
+	require.Equal(t, os.FileMode(0o755), dirMode)
+	require.Equal(t, os.FileMode(0o600), keyMode)
+	require.Equal(t, os.FileMode(0o750), symbolic)
+
+	for _, value := range []string{"0948", "01777", "rwxrwxrwz", "xwrxwrxwr"} {
+		environment := env.MakeEnvSet(func(string) (string, bool) { return value, true })
+
+		var mode os.FileMode
+		env.FlagVar(environment, &mode, "MODE")
+
+		require.EqualError(t, environment.Parse(), fmt.Sprintf("failed to parse MODE: invalid file mode %q", value))
+	}
+}
+
+func TestCommandLineArgsNegativeNumbers(t *testing.T) {
+	environment := env.MakeEnvSet(env.CommandLineArgs("--offset", "-5", "--ratio", "-0.5", "--verbose", "-x", "1"))
+
+	var (
+		offset  int
+		ratio   float64
+		verbose bool
+		x       int
+	)
+
+	env.FlagVar(environment, &offset, "OFFSET")
+	env.FlagVar(environment, &ratio, "RATIO")
+	env.FlagVar(environment, &verbose, "VERBOSE")
+	env.FlagVar(environment, &x, "X")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, -5, offset)
+	require.Equal(t, -0.5, ratio)
+	require.True(t, verbose)
+	require.Equal(t, 1, x)
+
+	for _, arg := range []string{"-inf", "-Inf", "-nan", "-0x1p3", "-1e3", "-.", "-1.2.3"} {
+		value, ok := env.CommandLineArgs("--level", arg, "2")("LEVEL")
+		require.True(t, ok, arg)
+		require.Equal(t, "true", value, arg)
+	}
+
+	value, ok := env.CommandLineArgs("--level", "-.5")("LEVEL")
+	require.True(t, ok)
+	require.Equal(t, "-.5", value)
+}
+
+func TestAllowedKeys(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"LIMITS": "retries=3,timeout=10",
+			"TYPOS":  "retires=3,timeout=10,tiemout=5",
+		}[name]
+		return value, ok
+	})
+
+	opts := env.Options[map[string]int]{AllowedKeys: []string{"retries", "timeout"}}
+
+	var limits, typos map[string]int
+
+	env.FlagVar(environment, &limits, "LIMITS", opts)
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string]int{"retries": 3, "timeout": 10}, limits)
+
+	env.FlagVar(environment, &typos, "TYPOS", opts)
+	require.EqualError(t, environment.Parse(), `invalid value for TYPOS: unknown keys ["retires" "tiemout"]: expected any of ["retries" "timeout"]`)
+}
+
+func TestSetStrictDefaults(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"HOST": "localhost"}[name]
+		return value, ok
+	})
+
+	var (
+		host    string
+		port    int
+		timeout time.Duration
+		tags    []string
+	)
+
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &timeout, "TIMEOUT", env.Options[time.Duration]{DefaultValue: time.Second})
+	env.FlagVar(environment, &tags, "TAGS", env.Options[[]string]{})
+
+	require.NoError(t, environment.Parse())
+	require.Empty(t, environment.Warnings())
+
+	environment.SetStrictDefaults(true)
+
+	require.NoError(t, environment.Parse())
+
+	warnings := environment.Warnings()
+	sort.Strings(warnings)
+	require.Equal(
+		t,
+		[]string{
+			"PORT is not set and has no default value: using the zero value",
+			"TAGS is not set and has no default value: using the zero value",
+		},
+		warnings,
+	)
+}
+
+func TestMakeEnvSetIsolated(t *testing.T) {
+	t.Setenv("ISOLATED_HOST", "from-os")
+
+	var host string
+
+	environment := env.MakeEnvSetIsolated()
+	env.FlagVar(environment, &host, "ISOLATED_HOST", env.Options[string]{DefaultValue: "default"})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "default", host)
+
+	environment = env.MakeEnvSetIsolated(nil, func(name string) (string, bool) { return "from-lookup", true })
+	env.FlagVar(environment, &host, "ISOLATED_HOST")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "from-lookup", host)
+
+	environment = env.MakeEnvSet()
+	env.FlagVar(environment, &host, "ISOLATED_HOST")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "from-os", host)
+}
+
+func TestDedup(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"REGIONS": "us,eu,us,asia,eu",
+			"WEIGHTS": "1,2,1,3",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		regions []string
+		weights []int
+	)
+
+	env.FlagVar(environment, &regions, "REGIONS", env.Options[[]string]{Dedup: true, MaxItems: 3})
+	env.FlagVar(environment, &weights, "WEIGHTS")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []string{"us", "eu", "asia"}, regions)
+	require.Equal(t, []int{1, 2, 1, 3}, weights)
+}
+
+func TestMissingRequired(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"HOST": "localhost", "PORT": "not a number"}[name]
+		return value, ok
+	})
+
+	var (
+		host, user, password string
+		port                 int
+		region               string
+		debug                bool
+	)
+
+	env.FlagVar(environment, &host, "HOST", env.Options[string]{Required: true})
+	env.FlagVar(environment, &port, "PORT", env.Options[int]{Required: true})
+	env.FlagVar(environment, &user, "USER", env.Options[string]{Required: true})
+	env.FlagVar(environment, &password, "PASSWORD", env.Options[string]{Required: true, Secret: true})
+	env.FlagVar(environment, &region, "REGION", env.Options[string]{
+		Required: true,
+		Lookup:   func(name string) (string, bool) { return "us-east-1", true },
+	})
+	env.FlagVar(environment, &debug, "DEBUG")
+
+	require.Equal(t, []string{"PASSWORD", "USER"}, environment.MissingRequired())
+
+	environment.SetDefaultLookup(func(name string) (string, bool) { return "admin", name == "USER" })
+	require.Equal(t, []string{"PASSWORD"}, environment.MissingRequired())
+}
+
+func TestRequireUnit(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"TIMEOUT": "30s", "INTERVAL": "30", "DELAY": "0"}[name]
+		return value, ok
+	})
+
+	opts := env.Options[time.Duration]{RequireUnit: true}
+
+	var timeout, interval, delay time.Duration
+
+	env.FlagVar(environment, &timeout, "TIMEOUT", opts)
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 30*time.Second, timeout)
+
+	env.FlagVar(environment, &interval, "INTERVAL", opts)
+	env.FlagVar(environment, &delay, "DELAY", opts)
+
+	err := environment.Parse()
+	require.ErrorContains(t, err, "failed to parse INTERVAL: duration requires a unit (e.g. 30s)")
+	require.ErrorContains(t, err, "failed to parse DELAY: duration requires a unit (e.g. 0s)")
+}
+
+func TestOnSet(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"PORT": "8080", "REPLICAS": "three"}[name]
+		return value, ok
+	})
+
+	type call struct {
+		raw   string
+		value int
+	}
+
+	calls := map[string][]call{}
+	onSet := func(name string) func(string, int) {
+		return func(raw string, value int) { calls[name] = append(calls[name], call{raw, value}) }
+	}
+
+	var port, workers, replicas int
+
+	env.FlagVar(environment, &port, "PORT", env.Options[int]{OnSet: onSet("PORT")})
+	env.FlagVar(environment, &workers, "WORKERS", env.Options[int]{DefaultValue: 4, OnSet: onSet("WORKERS")})
+
+	require.NoError(t, environment.Validate())
+	require.Empty(t, calls)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, map[string][]call{"PORT": {{"8080", 8080}}, "WORKERS": {{"", 4}}}, calls)
+
+	env.FlagVar(environment, &replicas, "REPLICAS", env.Options[int]{OnSet: onSet("REPLICAS")})
+
+	require.Error(t, environment.Parse())
+	require.NotContains(t, calls, "REPLICAS")
+}
+
+func TestPushOverride(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"PORT": "8080", "LEVEL": "info"}[name]
+		return value, ok
+	})
+
+	var (
+		port  int
+		level string
+	)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &level, "LEVEL")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 8080, port)
+	require.Equal(t, "info", level)
+
+	environment.PushOverride(func(name string) (string, bool) {
+		value, ok := map[string]string{"PORT": "9090", "LEVEL": "debug"}[name]
+		return value, ok
+	})
+	environment.Group("ADMIN_").PushOverride(func(name string) (string, bool) {
+		return "7070", name == "PORT"
+	})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 7070, port)
+	require.Equal(t, "debug", level)
+
+	environment.PopOverride()
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 9090, port)
+	require.Equal(t, "debug", level)
+
+	environment.PopOverride()
+	environment.PopOverride()
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 8080, port)
+	require.Equal(t, "info", level)
+}
+
+func TestPushOverrideLookup(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		return "true", name == "JSON"
+	})
+	environment.MutuallyExclusive("JSON", "YAML")
+
+	lookup := environment.Lookup()
+
+	_, ok := lookup("YAML")
+	require.False(t, ok)
+	require.NoError(t, environment.Parse())
+
+	environment.PushOverride(func(name string) (string, bool) { return "true", name == "YAML" })
+
+	value, ok := lookup("YAML")
+	require.True(t, ok)
+	require.Equal(t, "true", value)
+	require.EqualError(t, environment.Parse(), "variables are mutually exclusive: present: JSON, YAML")
+
+	environment.PopOverride()
+
+	_, ok = environment.Lookup()("YAML")
+	require.False(t, ok)
+}
+
+func TestCommandLineArgsMulti(t *testing.T) {
+	defaults := []string{"--port", "8080", "--host=localhost", "--tags", "a", "--tags", "b"}
+	user := []string{"--port=9090", "--tags", "c"}
+
+	environment := env.MakeEnvSet(env.CommandLineArgsMulti(defaults, nil, user))
+
+	var (
+		port int
+		host string
+		tags []string
+		name string
+	)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &tags, "TAGS")
+	env.FlagVar(environment, &name, "NAME")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 9090, port)
+	require.Equal(t, "localhost", host)
+	require.Equal(t, []string{"c"}, tags)
+	require.Equal(t, "", name)
+}
+
+func TestMapSeparators(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"SCHEDULE": "0 0 * * *=>backup;*/5 * * * *=>poll;0 9,17 * * 1-5=>report",
+			"FILTERS":  "level=>a=b;env=>prod",
+		}[name]
+		return value, ok
+	})
+
+	opts := env.Options[map[string]string]{Separator: ";", KeyValueSeparator: "=>"}
+
+	var schedule, filters map[string]string
+
+	env.FlagVar(environment, &schedule, "SCHEDULE", opts)
+	env.FlagVar(environment, &filters, "FILTERS", opts)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(
+		t,
+		map[string]string{
+			"0 0 * * *":      "backup",
+			"*/5 * * * *":    "poll",
+			"0 9,17 * * 1-5": "report",
+		},
+		schedule,
+	)
+	require.Equal(t, map[string]string{"level": "a=b", "env": "prod"}, filters)
+}
+
+func TestSorted(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"REGIONS":  "us,eu,asia,eu",
+			"PORTS":    "8080,443,80",
+			"TIMEOUTS": "5s,1s,3s",
+			"FLAGS":    "true,false",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		regions  []string
+		ports    []int
+		timeouts []time.Duration
+		flags    []bool
+	)
+
+	env.FlagVar(environment, &regions, "REGIONS", env.Options[[]string]{Sorted: true, Dedup: true})
+	env.FlagVar(environment, &ports, "PORTS", env.Options[[]int]{Sorted: true})
+	env.FlagVar(environment, &timeouts, "TIMEOUTS", env.Options[[]time.Duration]{Sorted: true})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []string{"asia", "eu", "us"}, regions)
+	require.Equal(t, []int{80, 443, 8080}, ports)
+	require.Equal(t, []time.Duration{time.Second, 3 * time.Second, 5 * time.Second}, timeouts)
+
+	env.FlagVar(environment, &flags, "FLAGS", env.Options[[]bool]{Sorted: true})
+	require.EqualError(t, environment.Parse(), "invalid value for FLAGS: cannot sort elements of type bool: not ordered")
+}
+
+func TestGroupConstraints(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{"AWS_KEY": "key", "OUTPUT_JSON": "true", "OUTPUT_YAML": "true"}[name]
+		return value, ok
+	})
+
+	aws := environment.Group("AWS")
+	aws.RequireAllOrNone("KEY", "SECRET")
+
+	output := environment.Group("OUTPUT")
+	output.MutuallyExclusive("JSON", "YAML")
+
+	for _, envset := range []env.EnvSet{environment, aws, output} {
+		err := envset.Parse()
+		require.ErrorContains(t, err, "variables must be set together: present: AWS_KEY; missing: AWS_SECRET")
+		require.ErrorContains(t, err, "variables are mutually exclusive: present: OUTPUT_JSON, OUTPUT_YAML")
+	}
+}
@@ -2,10 +2,20 @@ module github.com/davidmdm/env
 
 go 1.20
 
-require github.com/stretchr/testify v1.8.2
+require (
+	github.com/Azure/azure-sdk-for-go/sdk/azcore v1.16.0
+	github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/azsecrets v1.3.0
+	github.com/google/uuid v1.6.0
+	github.com/stretchr/testify v1.9.0
+	golang.org/x/sys v0.26.0
+	golang.org/x/text v0.19.0
+)
 
 require (
+	github.com/Azure/azure-sdk-for-go/sdk/internal v1.10.0 // indirect
+	github.com/Azure/azure-sdk-for-go/sdk/security/keyvault/internal v1.1.0 // indirect
 	github.com/davecgh/go-spew v1.1.1 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
+	golang.org/x/net v0.30.0 // indirect
 	gopkg.in/yaml.v3 v3.0.1 // indirect
 )
@@ -0,0 +1,44 @@
+// Package http provides a lookup backed by a document served over HTTP, such as one published by a config server.
+package http
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/davidmdm/env"
+)
+
+// Lookup fetches the document at url and returns a lookup over the variables it defines.
+// Documents served with a JSON content type are read as a JSON object, anything else is read as a dotenv file.
+// The document is fetched once: network failures, timeouts and non-2xx responses are returned as errors.
+// If client is nil http.DefaultClient is used.
+func Lookup(ctx context.Context, client *http.Client, url string) (env.LookupFunc, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("failed to fetch %s: unexpected status: %s", url, resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if mediaType == "application/json" || strings.HasSuffix(mediaType, "+json") {
+		return env.JSON(resp.Body)
+	}
+
+	return env.DotEnv(resp.Body)
+}
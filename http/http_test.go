@@ -0,0 +1,79 @@
+package http_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	envhttp "github.com/davidmdm/env/http"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLookup(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/config.json":
+			w.Header().Set("Content-Type", "application/json; charset=utf-8")
+			w.Write([]byte(`{"PORT": 8080, "HOSTS": ["a", "b"], "NAME": "api"}`))
+		case "/config.env":
+			w.Header().Set("Content-Type", "text/plain")
+			w.Write([]byte("PORT=9090\nNAME=\"worker\"\n"))
+		case "/slow":
+			time.Sleep(100 * time.Millisecond)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	t.Run("json", func(t *testing.T) {
+		lookup, err := envhttp.Lookup(context.Background(), server.Client(), server.URL+"/config.json")
+		require.NoError(t, err)
+
+		environment := env.MakeEnvSet(lookup)
+
+		var (
+			port  int
+			hosts []string
+			name  string
+		)
+
+		env.FlagVar(environment, &port, "PORT")
+		env.FlagVar(environment, &hosts, "HOSTS")
+		env.FlagVar(environment, &name, "NAME")
+
+		require.NoError(t, environment.Parse())
+		require.Equal(t, 8080, port)
+		require.Equal(t, []string{"a", "b"}, hosts)
+		require.Equal(t, "api", name)
+	})
+
+	t.Run("dotenv", func(t *testing.T) {
+		lookup, err := envhttp.Lookup(context.Background(), server.Client(), server.URL+"/config.env")
+		require.NoError(t, err)
+
+		value, ok := lookup("PORT")
+		require.True(t, ok)
+		require.Equal(t, "9090", value)
+
+		value, ok = lookup("NAME")
+		require.True(t, ok)
+		require.Equal(t, "worker", value)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := envhttp.Lookup(context.Background(), server.Client(), server.URL+"/missing")
+		require.ErrorContains(t, err, "unexpected status: 404 Not Found")
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+
+		_, err := envhttp.Lookup(ctx, server.Client(), server.URL+"/slow")
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+}
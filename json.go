@@ -0,0 +1,128 @@
+package env
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JSON reads a JSON object from r and returns a lookup over its top-level keys.
+// String values are returned as is, arrays are joined with commas so that they can be parsed into slices,
+// and any other value is returned as its JSON text. Keys with a null value are treated as absent.
+func JSON(r io.Reader) (LookupFunc, error) {
+	// Numbers are decoded as json.Number so that they are returned as they are written,
+	// rather than through a float64 that loses the precision of large integers.
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+
+	var document map[string]any
+	if err := decoder.Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode json: %w", err)
+	}
+
+	vars := make(map[string]string, len(document))
+	for key, value := range document {
+		if value == nil {
+			continue
+		}
+		text, err := stringifyJSON(value)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+		vars[key] = text
+	}
+
+	return mapLookup(vars), nil
+}
+
+func stringifyJSON(value any) (string, error) {
+	switch value := value.(type) {
+	case string:
+		return value, nil
+	case json.Number:
+		return value.String(), nil
+	case []any:
+		items := make([]string, len(value))
+		for i, item := range value {
+			text, err := stringifyJSON(item)
+			if err != nil {
+				return "", err
+			}
+			items[i] = text
+		}
+		return strings.Join(items, ","), nil
+	default:
+		data, err := json.Marshal(value)
+		return string(data), err
+	}
+}
+
+// VarUnion registers a variable holding a JSON object whose "type" field selects the constructor of byType used to create its value,
+// into which the object is then decoded. It supports polymorphic configuration such as:
+//
+//	STORAGE={"type": "s3", "bucket": "assets"}
+//
+// Constructors typically return a pointer to a concrete type as the interface type T.
+func VarUnion[T any](envset EnvSet, p *T, name string, byType map[string]func() T, opts ...Options[T]) {
+	envset.register(envset.prefix+name, flag{
+		value: unionValue[T]{p, byType},
+		opts:  multiOpts[T](opts).toFlagOptions(),
+	})
+}
+
+// unionValue is a value decoded from a JSON object into the type selected by its "type" field.
+type unionValue[T any] struct {
+	dst    *T
+	byType map[string]func() T
+}
+
+func (v unionValue[T]) Set(value any) {
+	*v.dst, _ = value.(T)
+}
+
+func (v unionValue[T]) Get() any {
+	return *v.dst
+}
+
+func (v unionValue[T]) Type() reflect.Type {
+	return reflect.TypeOf(v.dst).Elem()
+}
+
+func (v unionValue[T]) scratch() value {
+	dst := new(T)
+	*dst = *v.dst
+	return unionValue[T]{dst, v.byType}
+}
+
+func (v unionValue[T]) Parse(text string, _ flagOptions) error {
+	var header struct {
+		Type *string `json:"type"`
+	}
+	if err := json.Unmarshal([]byte(text), &header); err != nil {
+		return err
+	}
+	if header.Type == nil {
+		return fmt.Errorf(`missing "type" field`)
+	}
+
+	construct, ok := v.byType[*header.Type]
+	if !ok {
+		types := make([]string, 0, len(v.byType))
+		for key := range v.byType {
+			types = append(types, key)
+		}
+		sort.Strings(types)
+		return fmt.Errorf("expected type to be one of %q but got %q", types, *header.Type)
+	}
+
+	result := construct()
+	if err := json.Unmarshal([]byte(text), &result); err != nil {
+		return err
+	}
+
+	*v.dst = result
+	return nil
+}
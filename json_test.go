@@ -0,0 +1,94 @@
+package env_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+type Storage interface{ Kind() string }
+
+type S3Storage struct {
+	Bucket string `json:"bucket"`
+}
+
+func (*S3Storage) Kind() string { return "s3" }
+
+type DiskStorage struct {
+	Path string `json:"path"`
+}
+
+func (*DiskStorage) Kind() string { return "disk" }
+
+func TestVarUnion(t *testing.T) {
+	byType := map[string]func() Storage{
+		"s3":   func() Storage { return new(S3Storage) },
+		"disk": func() Storage { return new(DiskStorage) },
+	}
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"PRIMARY":   `{"type": "s3", "bucket": "assets"}`,
+			"SECONDARY": `{"type": "disk", "path": "/var/data"}`,
+			"UNKNOWN":   `{"type": "ftp"}`,
+			"UNTYPED":   `{"path": "/tmp"}`,
+		}[name]
+		return value, ok
+	})
+
+	var primary, secondary Storage
+
+	env.VarUnion(environment, &primary, "PRIMARY", byType)
+	env.VarUnion(environment, &secondary, "SECONDARY", byType)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, &S3Storage{Bucket: "assets"}, primary)
+	require.Equal(t, &DiskStorage{Path: "/var/data"}, secondary)
+
+	for _, tc := range []struct {
+		Name  string
+		Error string
+	}{
+		{Name: "UNKNOWN", Error: `failed to parse UNKNOWN: expected type to be one of ["disk" "s3"] but got "ftp"`},
+		{Name: "UNTYPED", Error: `failed to parse UNTYPED: missing "type" field`},
+	} {
+		environment := env.MakeEnvSet(environment.Lookup())
+
+		var storage Storage
+		env.VarUnion(environment, &storage, tc.Name, byType)
+
+		require.EqualError(t, environment.Parse(), tc.Error)
+	}
+}
+
+func TestJSONNumbers(t *testing.T) {
+	lookup, err := env.JSON(strings.NewReader(`{
+		"PORT": 8080,
+		"ID": 9007199254740993,
+		"RATIO": 0.25,
+		"LIMITS": {"max": 12345678901234567}
+	}`))
+	require.NoError(t, err)
+
+	environment := env.MakeEnvSet(lookup)
+
+	var (
+		port   int
+		id     int64
+		ratio  float64
+		limits string
+	)
+
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &id, "ID")
+	env.FlagVar(environment, &ratio, "RATIO")
+	env.FlagVar(environment, &limits, "LIMITS")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 8080, port)
+	require.Equal(t, int64(9007199254740993), id)
+	require.Equal(t, 0.25, ratio)
+	require.Equal(t, `{"max":12345678901234567}`, limits)
+}
@@ -0,0 +1,28 @@
+// Package lang registers a decoder for lists of the language tags of golang.org/x/text/language, which are parsed like
+// an Accept-Language header: LOCALES=fr-CH,fr;q=0.9,en;q=0.8 is parsed into a []language.Tag ordered by preference.
+// It is imported for its side effects:
+//
+//	import _ "github.com/davidmdm/env/lang"
+//
+// A single language.Tag, such as LOCALE=en-US, implements encoding.TextUnmarshaler and does not need this package.
+package lang
+
+import (
+	"reflect"
+
+	"github.com/davidmdm/env"
+	"golang.org/x/text/language"
+)
+
+func init() {
+	env.RegisterDecoder(reflect.TypeOf([]language.Tag{}), decodeTags)
+}
+
+func decodeTags(text string, v reflect.Value) error {
+	tags, _, err := language.ParseAcceptLanguage(text)
+	if err != nil {
+		return err
+	}
+	v.Set(reflect.ValueOf(tags))
+	return nil
+}
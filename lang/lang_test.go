@@ -0,0 +1,54 @@
+package lang_test
+
+import (
+	"testing"
+
+	"github.com/davidmdm/env"
+	_ "github.com/davidmdm/env/lang"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/text/language"
+)
+
+func TestLanguageTags(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"LOCALE":  "en-US",
+			"LOCALES": "fr-CH, en;q=0.8, fr;q=0.9",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		locale  language.Tag
+		locales []language.Tag
+	)
+
+	env.FlagVar(environment, &locale, "LOCALE")
+	env.FlagVar(environment, &locales, "LOCALES")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, language.AmericanEnglish, locale)
+	require.Equal(t, []language.Tag{language.MustParse("fr-CH"), language.French, language.English}, locales)
+}
+
+func TestLanguageTagErrors(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"LOCALE":  "en-US-",
+			"LOCALES": "en;q=high",
+		}[name]
+		return value, ok
+	})
+
+	var locale language.Tag
+	env.FlagVar(environment, &locale, "LOCALE")
+
+	require.ErrorContains(t, environment.Parse(), "failed to parse LOCALE: language: tag is not well-formed")
+
+	environment = env.MakeEnvSet(environment.Lookup())
+
+	var locales []language.Tag
+	env.FlagVar(environment, &locales, "LOCALES")
+
+	require.ErrorContains(t, environment.Parse(), "failed to parse LOCALES: ")
+}
@@ -0,0 +1,22 @@
+package env
+
+// LogConfig holds the usual settings of a logger, to be mapped onto the logging library of choice.
+type LogConfig struct {
+	// Level is the minimum level of the records to log, such as debug or info.
+	Level string
+	// Format is the encoding of the records: json or text.
+	Format string
+	// Output is where records are written, such as stderr or the path of a file.
+	Output string
+}
+
+// VarLog registers the fields of p as PREFIX_LEVEL, PREFIX_FORMAT and PREFIX_OUTPUT.
+// Fields that are not set keep their current value, so that defaults can be set beforehand. The format must be json or text.
+func VarLog(envset EnvSet, p *LogConfig, prefix string) {
+	FlagVar(envset, &p.Level, prefix+"_LEVEL", Options[string]{DefaultValue: p.Level})
+	FlagVar(envset, &p.Format, prefix+"_FORMAT", Options[string]{
+		DefaultValue: p.Format,
+		Validate:     OneOfStrings("json", "text"),
+	})
+	FlagVar(envset, &p.Output, prefix+"_OUTPUT", Options[string]{DefaultValue: p.Output})
+}
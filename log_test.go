@@ -0,0 +1,30 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarLog(t *testing.T) {
+	vars := map[string]string{"LOG_LEVEL": "debug", "LOG_FORMAT": "json"}
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	})
+
+	config := env.LogConfig{Level: "info", Format: "text", Output: "stderr"}
+	env.VarLog(environment, &config, "LOG")
+
+	require.Equal(t, []string{"LOG_FORMAT", "LOG_LEVEL", "LOG_OUTPUT"}, environment.Names())
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, env.LogConfig{Level: "debug", Format: "json", Output: "stderr"}, config)
+
+	vars["LOG_FORMAT"] = "logfmt"
+	vars["LOG_OUTPUT"] = "/var/log/app.log"
+
+	require.EqualError(t, environment.Parse(), `invalid value for LOG_FORMAT: expected value to be one of ["json" "text"] but got "logfmt"`)
+}
@@ -1,19 +1,182 @@
 package env
 
 type flagOptions struct {
-	required bool
-	fallback any
+	required           bool
+	fallback           any
+	extendedDuration   bool
+	keyTransform       func(string) string
+	separatorPrefix    bool
+	mergeSources       bool
+	skipEmpty          bool
+	thousandsSeparator rune
+	description        string
+	validate           func(any) error
+	fromFile           bool
+	fallbackOnError    bool
+	secret             bool
+	presenceIsTrue     bool
+	queryString        bool
+	nestedUnmarshalers bool
+	autoDecode         bool
+	minItems           int
+	maxItems           int
+	lookup             LookupFunc
+	fromFiles          []string
+	emptyCollection    bool
+	allowedKeys        []string
+	dedup              bool
+	separator          string
+	requireUnit        bool
+	onSet              func(raw string, value any)
+	keyValueSeparator  string
+	sorted             bool
 }
 
 type Options[T any] struct {
 	Required     bool
 	DefaultValue T
+
+	// ExtendedDuration allows time.Duration values to use the d (day) and w (week) units.
+	ExtendedDuration bool
+
+	// KeyTransform is applied to each key of a map before it is parsed, for example strings.ToLower.
+	KeyTransform func(string) string
+
+	// SeparatorPrefix lets slice values declare their own separator using a leading @,
+	// for example @|a|b|c is split on | instead of the default comma.
+	SeparatorPrefix bool
+
+	// MergeSources joins the values found in every lookup source instead of using only the first source that has the variable.
+	// It is intended for map variables: entries from all sources are combined and sources of higher precedence win on conflicting keys.
+	MergeSources bool
+
+	// SkipEmpty drops blank elements when splitting a slice, such as those produced by leading, trailing or doubled separators.
+	SkipEmpty bool
+
+	// ThousandsSeparator is stripped from scalar numeric values before they are parsed, for example 1,000,000.
+	// It has no effect on the elements of slices and maps.
+	ThousandsSeparator rune
+
+	// Description documents the variable, for example in the output of GenerateEnvTemplate.
+	Description string
+
+	// Validate is called with the parsed value of the variable. An error fails the parse.
+	Validate func(T) error
+
+	// FromFile treats the value of a []byte variable as a path and reads the contents of that file instead.
+	FromFile bool
+
+	// FallbackOnError uses the default value when the variable fails to parse, recording a warning instead of failing the parse.
+	// See EnvSet.Warnings.
+	FallbackOnError bool
+
+	// Secret marks the variable as sensitive so that its value is redacted wherever it is reported.
+	Secret bool
+
+	// PresenceIsTrue makes a bool variable that is set to an empty value true, so that VERBOSE= behaves like a bare --verbose flag.
+	PresenceIsTrue bool
+
+	// QueryString parses map variables as URL query strings, such as a=1&b=2, instead of comma separated key=value pairs.
+	// Keys and values are unescaped, and repeated keys are collected when the values of the map are slices.
+	QueryString bool
+
+	// NestedUnmarshalers changes how slices of encoding.TextUnmarshaler elements are split: separators within brackets,
+	// braces or double quotes do not split elements, so that each element can be a JSON document for example.
+	// It also allows one more level of nesting for such slices, such as [][]T, where inner slices are wrapped in brackets: [a,b],[c].
+	NestedUnmarshalers bool
+
+	// AutoDecode decodes the value of a []byte variable when it is standard base64, and uses the raw bytes otherwise.
+	// As plain text such as words and paths can be valid base64 too, the value is only decoded when it is padded,
+	// or when it is not itself made of lower case letters, digits and slashes only, and either decodes to printable text
+	// or is at least 32 characters long, like the encoding of a 24 byte key.
+	// Prefer plain values or a dedicated base64 type when the format is known.
+	AutoDecode bool
+
+	// MinItems and MaxItems bound the number of elements of slice and map variables. A MaxItems of zero means no maximum.
+	MinItems int
+	MaxItems int
+
+	// Lookup resolves this variable before the lookup of the EnvSet, which is still used when Lookup does not find it.
+	// It allows a single variable to be sourced differently from the rest, for example from a file.
+	Lookup LookupFunc
+
+	// FromFiles lists files whose contents are concatenated to form the value of the variable when it is not found by the lookup,
+	// for example a certificate chain split across several PEM files. Every file must exist.
+	FromFiles []string
+
+	// EmptyCollection sets slice and map variables that are not found, and have no default value, to an empty collection instead of nil.
+	EmptyCollection bool
+
+	// AllowedKeys restricts the keys of map variables to the given set, catching typos in map-style configuration.
+	// Keys are compared in their text form.
+	AllowedKeys []string
+
+	// Dedup removes duplicate elements of slice variables, keeping the first occurrence of each.
+	// The elements must be comparable.
+	Dedup bool
+
+	// Sorted sorts the elements of slice variables in ascending order, for deterministic values when the order does not matter.
+	// The elements must be strings or numbers.
+	Sorted bool
+
+	// Separator splits the elements of slices and the entries of maps instead of the default comma, for example ";".
+	Separator string
+
+	// KeyValueSeparator splits the key of each map entry from its value instead of the default =.
+	// Together with Separator it allows keys that contain commas or equal signs, such as cron expressions:
+	// with a Separator of ; and a KeyValueSeparator of => the value 0 9,17 * * 1-5=>report;*/5 * * * *=>poll has two entries.
+	KeyValueSeparator string
+
+	// RequireUnit rejects time.Duration values that are bare numbers, including 0, so that 30 fails with an error asking for 30s
+	// rather than leaving the reader to guess whether seconds or milliseconds were meant.
+	RequireUnit bool
+
+	// OnSet is called with the raw value of the variable and the value it was set to, after it is successfully parsed.
+	// It is called for default values too, with an empty raw value. It is not called by Validate or other dry runs.
+	OnSet func(raw string, value T)
 }
 
 func (opts Options[T]) toFlagOptions() flagOptions {
+	var validate func(any) error
+	if opts.Validate != nil {
+		validate = func(value any) error { return opts.Validate(value.(T)) }
+	}
+
+	var onSet func(string, any)
+	if opts.OnSet != nil {
+		onSet = func(raw string, value any) { opts.OnSet(raw, value.(T)) }
+	}
+
 	return flagOptions{
-		required: opts.Required,
-		fallback: opts.DefaultValue,
+		required:           opts.Required,
+		fallback:           opts.DefaultValue,
+		extendedDuration:   opts.ExtendedDuration,
+		keyTransform:       opts.KeyTransform,
+		separatorPrefix:    opts.SeparatorPrefix,
+		mergeSources:       opts.MergeSources,
+		skipEmpty:          opts.SkipEmpty,
+		thousandsSeparator: opts.ThousandsSeparator,
+		description:        opts.Description,
+		validate:           validate,
+		fromFile:           opts.FromFile,
+		fallbackOnError:    opts.FallbackOnError,
+		secret:             opts.Secret,
+		presenceIsTrue:     opts.PresenceIsTrue,
+		queryString:        opts.QueryString,
+		nestedUnmarshalers: opts.NestedUnmarshalers,
+		autoDecode:         opts.AutoDecode,
+		minItems:           opts.MinItems,
+		maxItems:           opts.MaxItems,
+		lookup:             opts.Lookup,
+		fromFiles:          opts.FromFiles,
+		emptyCollection:    opts.EmptyCollection,
+		allowedKeys:        opts.AllowedKeys,
+		dedup:              opts.Dedup,
+		separator:          opts.Separator,
+		requireUnit:        opts.RequireUnit,
+		onSet:              onSet,
+		keyValueSeparator:  opts.KeyValueSeparator,
+		sorted:             opts.Sorted,
 	}
 }
 
@@ -0,0 +1,71 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// OrderedMap is a map parsed from comma separated key=value pairs that remembers the order in which its keys were given,
+// for configuration where order matters such as a chain of middlewares: MIDDLEWARES=auth=strict,gzip=6,log=json.
+// A repeated key updates its value but keeps its original position.
+type OrderedMap[K comparable, V any] struct {
+	keys   []K
+	values map[K]V
+}
+
+// Keys returns the keys of the map in the order they were given.
+func (m OrderedMap[K, V]) Keys() []K {
+	return m.keys
+}
+
+// Get returns the value of key and whether it is present.
+func (m OrderedMap[K, V]) Get(key K) (V, bool) {
+	value, ok := m.values[key]
+	return value, ok
+}
+
+// Len returns the number of keys of the map.
+func (m OrderedMap[K, V]) Len() int {
+	return len(m.keys)
+}
+
+func (m OrderedMap[K, V]) MarshalText() ([]byte, error) {
+	items := make([]string, len(m.keys))
+	for i, key := range m.keys {
+		items[i] = format(key) + "=" + format(m.values[key])
+	}
+	return []byte(strings.Join(items, ",")), nil
+}
+
+func (m *OrderedMap[K, V]) UnmarshalText(text []byte) error {
+	*m = OrderedMap[K, V]{values: map[K]V{}}
+
+	if strings.TrimSpace(string(text)) == "" {
+		return nil
+	}
+
+	for _, elem := range strings.Split(string(text), ",") {
+		key, value, ok := strings.Cut(elem, "=")
+		if !ok {
+			return fmt.Errorf("expected key=value but got %q", elem)
+		}
+
+		var k K
+		if err := parse(reflect.ValueOf(&k).Elem(), key, flagOptions{}, false); err != nil {
+			return fmt.Errorf("failed to parse key: %s: %w", key, err)
+		}
+
+		var v V
+		if err := parse(reflect.ValueOf(&v).Elem(), value, flagOptions{}, false); err != nil {
+			return fmt.Errorf("failed to parse value at key: %s: %w", key, err)
+		}
+
+		if _, exists := m.values[k]; !exists {
+			m.keys = append(m.keys, k)
+		}
+		m.values[k] = v
+	}
+
+	return nil
+}
@@ -0,0 +1,39 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOrderedMap(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "zlib=9,auth=1,gzip=6,auth=2", true })
+
+	var middlewares env.OrderedMap[string, int]
+	env.FlagVar(environment, &middlewares, "MIDDLEWARES")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []string{"zlib", "auth", "gzip"}, middlewares.Keys())
+	require.Equal(t, 3, middlewares.Len())
+
+	value, ok := middlewares.Get("auth")
+	require.True(t, ok)
+	require.Equal(t, 2, value)
+
+	_, ok = middlewares.Get("log")
+	require.False(t, ok)
+
+	text, err := middlewares.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "zlib=9,auth=2,gzip=6", string(text))
+}
+
+func TestOrderedMapErrors(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "a=1,b=x", true })
+
+	var m env.OrderedMap[string, int]
+	env.FlagVar(environment, &m, "M")
+
+	require.EqualError(t, environment.Parse(), `failed to parse M: failed to parse value at key: b: strconv.ParseInt: parsing "x": invalid syntax`)
+}
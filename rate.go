@@ -0,0 +1,53 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate is a number of events per second, parsed from forms such as 100/s, 10/m, 5/h, 1/500ms or a bare number of events per second.
+// It converts directly to a golang.org/x/time/rate.Limit: rate.Limit(r).
+type Rate float64
+
+// Every returns the interval between events, or zero if the rate is not positive.
+func (r Rate) Every() time.Duration {
+	if r <= 0 {
+		return 0
+	}
+	return time.Duration(float64(time.Second) / float64(r))
+}
+
+func (r Rate) MarshalText() ([]byte, error) {
+	return []byte(strconv.FormatFloat(float64(r), 'f', -1, 64) + "/s"), nil
+}
+
+func (r *Rate) UnmarshalText(text []byte) error {
+	count, per, hasUnit := strings.Cut(strings.TrimSpace(string(text)), "/")
+
+	events, err := strconv.ParseFloat(strings.TrimSpace(count), 64)
+	if err != nil || events < 0 {
+		return fmt.Errorf("invalid rate %q: expected a non-negative number of events", text)
+	}
+
+	interval := time.Second
+	if hasUnit {
+		per = strings.TrimSpace(per)
+		switch per {
+		case "s":
+			interval = time.Second
+		case "m":
+			interval = time.Minute
+		case "h":
+			interval = time.Hour
+		default:
+			if interval, err = time.ParseDuration(per); err != nil || interval <= 0 {
+				return fmt.Errorf("invalid rate %q: expected a unit such as s, m, h or a positive duration", text)
+			}
+		}
+	}
+
+	*r = Rate(events / interval.Seconds())
+	return nil
+}
@@ -0,0 +1,50 @@
+package env_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRate(t *testing.T) {
+	for _, tc := range []struct {
+		Value    string
+		Expected env.Rate
+	}{
+		{Value: "100/s", Expected: 100},
+		{Value: "30/m", Expected: 0.5},
+		{Value: "3600/h", Expected: 1},
+		{Value: "1/500ms", Expected: 2},
+		{Value: "25", Expected: 25},
+	} {
+		t.Run(tc.Value, func(t *testing.T) {
+			environment := env.MakeEnvSet(func(string) (string, bool) { return tc.Value, true })
+
+			var rate env.Rate
+			env.FlagVar(environment, &rate, "RATE")
+
+			require.NoError(t, environment.Parse())
+			require.Equal(t, tc.Expected, rate)
+		})
+	}
+
+	require.Equal(t, 2*time.Second, env.Rate(0.5).Every())
+	require.Equal(t, time.Duration(0), env.Rate(0).Every())
+}
+
+func TestRateErrors(t *testing.T) {
+	for _, tc := range []struct {
+		Value string
+		Error string
+	}{
+		{Value: "fast", Error: `invalid rate "fast": expected a non-negative number of events`},
+		{Value: "-1/s", Error: `invalid rate "-1/s": expected a non-negative number of events`},
+		{Value: "10/day", Error: `invalid rate "10/day": expected a unit such as s, m, h or a positive duration`},
+		{Value: "10/0s", Error: `invalid rate "10/0s": expected a unit such as s, m, h or a positive duration`},
+	} {
+		var rate env.Rate
+		require.EqualError(t, rate.UnmarshalText([]byte(tc.Value)), tc.Error)
+	}
+}
@@ -0,0 +1,132 @@
+package env
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+// GenerateJSONSchema renders a JSON Schema describing the variables registered on the EnvSet as the properties of an object,
+// for integration with tools such as configuration UIs. The type of each property is inferred from the type of its destination:
+// values parsed from text, such as durations or types implementing encoding.TextUnmarshaler, are strings.
+// Required variables are listed as required, while the others document their default value unless they are Secret.
+func GenerateJSONSchema(envset EnvSet) ([]byte, error) {
+	var (
+		properties = make(map[string]any, len(envset.flags))
+		required   = []string{}
+	)
+
+	for _, name := range envset.Names() {
+		flag := envset.flags[name]
+
+		property := schemaFor(flag.value.Type())
+		if flag.opts.description != "" {
+			property["description"] = flag.opts.description
+		}
+		if flag.opts.required {
+			required = append(required, name)
+		} else if flag.opts.fallback != nil && !flag.opts.secret {
+			if fallback, ok := schemaDefault(reflect.ValueOf(flag.opts.fallback)); ok {
+				property["default"] = fallback
+			}
+		}
+
+		properties[name] = property
+	}
+
+	return json.MarshalIndent(
+		map[string]any{
+			"$schema":    "https://json-schema.org/draft/2020-12/schema",
+			"type":       "object",
+			"properties": properties,
+			"required":   required,
+		},
+		"",
+		"  ",
+	)
+}
+
+// schemaFor returns the schema of values of type t.
+func schemaFor(t reflect.Type) map[string]any {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	if isTextSchema(t) {
+		return map[string]any{"type": "string"}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]any{"type": "string"}
+		}
+		return map[string]any{"type": "array", "items": schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schemaFor(t.Elem())}
+	default:
+		return map[string]any{"type": "string"}
+	}
+}
+
+// schemaDefault converts v to a value whose JSON encoding matches the schema of its type.
+// It reports false for values that cannot be encoded, such as the functions of VarChoice.
+func schemaDefault(v reflect.Value) (any, bool) {
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, true
+		}
+		v = v.Elem()
+	}
+
+	t := v.Type()
+	if isTextSchema(t) || t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+		return format(v.Interface()), true
+	}
+
+	switch t.Kind() {
+	case reflect.Slice:
+		items := make([]any, v.Len())
+		for i := range items {
+			item, ok := schemaDefault(v.Index(i))
+			if !ok {
+				return nil, false
+			}
+			items[i] = item
+		}
+		return items, true
+	case reflect.Map:
+		entries := make(map[string]any, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			entry, ok := schemaDefault(iter.Value())
+			if !ok {
+				return nil, false
+			}
+			entries[format(iter.Key().Interface())] = entry
+		}
+		return entries, true
+	case reflect.Func, reflect.Chan, reflect.UnsafePointer, reflect.Complex64, reflect.Complex128:
+		return nil, false
+	case reflect.Interface:
+		if v.IsNil() {
+			return nil, true
+		}
+		return schemaDefault(v.Elem())
+	default:
+		return v.Interface(), true
+	}
+}
+
+// isTextSchema reports whether values of type t are parsed from text as a whole rather than by kind.
+func isTextSchema(t reflect.Type) bool {
+	if _, ok := lookupDecoder(t); ok {
+		return true
+	}
+	return isTextUnmarshaler(t)
+}
@@ -0,0 +1,142 @@
+package env_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateJSONSchema(t *testing.T) {
+	environment := env.MakeEnvSet()
+
+	var (
+		databaseURL string
+		port        int
+		ratio       float64
+		timeout     time.Duration
+		hosts       []string
+		limits      map[string]int
+		debug       bool
+		color       env.Color
+	)
+
+	env.FlagVar(environment, &databaseURL, "DATABASE_URL", env.Options[string]{Required: true, Description: "postgres connection string"})
+	env.FlagVar(environment, &port, "PORT", env.Options[int]{DefaultValue: 8080})
+	env.FlagVar(environment, &ratio, "RATIO", env.Options[float64]{DefaultValue: 0.5})
+	env.FlagVar(environment, &timeout, "TIMEOUT", env.Options[time.Duration]{DefaultValue: 5 * time.Second})
+	env.FlagVar(environment, &hosts, "HOSTS", env.Options[[]string]{DefaultValue: []string{"a", "b"}})
+	env.FlagVar(environment, &limits, "LIMITS")
+	env.FlagVar(environment, &debug, "DEBUG")
+	env.FlagVar(environment, &color, "COLOR", env.Options[env.Color]{DefaultValue: env.Color{R: 0xff, A: 0xff}})
+
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "properties": {
+    "COLOR": {
+      "default": "#ff0000",
+      "type": "string"
+    },
+    "DATABASE_URL": {
+      "description": "postgres connection string",
+      "type": "string"
+    },
+    "DEBUG": {
+      "default": false,
+      "type": "boolean"
+    },
+    "HOSTS": {
+      "default": [
+        "a",
+        "b"
+      ],
+      "items": {
+        "type": "string"
+      },
+      "type": "array"
+    },
+    "LIMITS": {
+      "additionalProperties": {
+        "type": "integer"
+      },
+      "default": {},
+      "type": "object"
+    },
+    "PORT": {
+      "default": 8080,
+      "type": "integer"
+    },
+    "RATIO": {
+      "default": 0.5,
+      "type": "number"
+    },
+    "TIMEOUT": {
+      "default": "5s",
+      "type": "string"
+    }
+  },
+  "required": [
+    "DATABASE_URL"
+  ],
+  "type": "object"
+}`
+
+	schema, err := env.GenerateJSONSchema(environment)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(schema))
+}
+
+func TestGenerateJSONSchemaChoice(t *testing.T) {
+	environment := env.MakeEnvSet()
+
+	var strategy func() int
+	env.VarChoice(environment, &strategy, "STRATEGY", map[string]func() int{
+		"one": func() int { return 1 },
+		"two": func() int { return 2 },
+	})
+
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "properties": {
+    "STRATEGY": {
+      "type": "string"
+    }
+  },
+  "required": [],
+  "type": "object"
+}`
+
+	schema, err := env.GenerateJSONSchema(environment)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(schema))
+}
+
+func TestGenerateJSONSchemaSecret(t *testing.T) {
+	environment := env.MakeEnvSet()
+
+	var password, token string
+
+	env.FlagVar(environment, &password, "PASSWORD", env.Options[string]{DefaultValue: "hunter2", Secret: true})
+	require.NoError(t, env.RegisterAll(environment, []env.VarSpec{
+		{Name: "TOKEN", Ptr: &token, Default: "s3cr3t", Secret: true},
+	}))
+
+	expected := `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "properties": {
+    "PASSWORD": {
+      "type": "string"
+    },
+    "TOKEN": {
+      "type": "string"
+    }
+  },
+  "required": [],
+  "type": "object"
+}`
+
+	schema, err := env.GenerateJSONSchema(environment)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(schema))
+}
@@ -0,0 +1,134 @@
+package env
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Semver is a semantic version such as 1.2.3, 1.2.3-rc.1 or 1.2.3+build.5. A leading v is accepted when parsing.
+type Semver struct {
+	Major, Minor, Patch int
+	Prerelease          string
+	Build               string
+}
+
+func (v Semver) String() string {
+	text := fmt.Sprintf("%d.%d.%d", v.Major, v.Minor, v.Patch)
+	if v.Prerelease != "" {
+		text += "-" + v.Prerelease
+	}
+	if v.Build != "" {
+		text += "+" + v.Build
+	}
+	return text
+}
+
+func (v Semver) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v *Semver) UnmarshalText(text []byte) error {
+	version, err := parseSemver(string(text))
+	if err != nil {
+		return err
+	}
+	*v = version
+	return nil
+}
+
+// Compare returns -1, 0 or 1 depending on whether v precedes, equals or follows other.
+// Build metadata is ignored, and a prerelease precedes its release.
+func (v Semver) Compare(other Semver) int {
+	for _, pair := range [][2]int{{v.Major, other.Major}, {v.Minor, other.Minor}, {v.Patch, other.Patch}} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case v.Prerelease == other.Prerelease:
+		return 0
+	case v.Prerelease == "":
+		return 1
+	case other.Prerelease == "":
+		return -1
+	}
+
+	left, right := strings.Split(v.Prerelease, "."), strings.Split(other.Prerelease, ".")
+	for i := 0; i < len(left) && i < len(right); i++ {
+		if c := comparePrereleaseIdentifiers(left[i], right[i]); c != 0 {
+			return c
+		}
+	}
+	return compareInts(len(left), len(right))
+}
+
+// VersionAtLeast returns a validator that requires the version to be equal to or greater than min.
+func VersionAtLeast(min string) func(Semver) error {
+	minimum, err := parseSemver(min)
+	return func(version Semver) error {
+		if err != nil {
+			return fmt.Errorf("invalid minimum version: %w", err)
+		}
+		if version.Compare(minimum) < 0 {
+			return fmt.Errorf("expected version to be at least %s but got %s", minimum, version)
+		}
+		return nil
+	}
+}
+
+func parseSemver(text string) (Semver, error) {
+	var (
+		version Semver
+		rest    = strings.TrimPrefix(strings.TrimSpace(text), "v")
+		ok      bool
+	)
+
+	rest, version.Build, _ = strings.Cut(rest, "+")
+	rest, version.Prerelease, ok = strings.Cut(rest, "-")
+	if ok && version.Prerelease == "" {
+		return Semver{}, fmt.Errorf("invalid version %q: empty prerelease", text)
+	}
+
+	parts := strings.Split(rest, ".")
+	if len(parts) != 3 {
+		return Semver{}, fmt.Errorf("invalid version %q: expected major.minor.patch", text)
+	}
+
+	for i, dst := range []*int{&version.Major, &version.Minor, &version.Patch} {
+		n, err := strconv.Atoi(parts[i])
+		if err != nil || n < 0 || len(parts[i]) > 1 && parts[i][0] == '0' {
+			return Semver{}, fmt.Errorf("invalid version %q: invalid number %q", text, parts[i])
+		}
+		*dst = n
+	}
+
+	return version, nil
+}
+
+func comparePrereleaseIdentifiers(a, b string) int {
+	x, errA := strconv.Atoi(a)
+	y, errB := strconv.Atoi(b)
+	switch {
+	case errA == nil && errB == nil:
+		return compareInts(x, y)
+	case errA == nil:
+		return -1
+	case errB == nil:
+		return 1
+	default:
+		return strings.Compare(a, b)
+	}
+}
+
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	default:
+		return 0
+	}
+}
@@ -0,0 +1,54 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSemver(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"VERSION":     "v1.2.3-rc.1+build.5",
+			"MIN_VERSION": "1.10.0",
+		}[name]
+		return value, ok
+	})
+
+	var version, minVersion env.Semver
+
+	env.FlagVar(environment, &version, "VERSION")
+	env.FlagVar(environment, &minVersion, "MIN_VERSION", env.Options[env.Semver]{Validate: env.VersionAtLeast("1.2.0")})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, env.Semver{Major: 1, Minor: 2, Patch: 3, Prerelease: "rc.1", Build: "build.5"}, version)
+	require.Equal(t, "1.2.3-rc.1+build.5", version.String())
+	require.Equal(t, env.Semver{Major: 1, Minor: 10}, minVersion)
+
+	env.FlagVar(environment, &version, "VERSION", env.Options[env.Semver]{Validate: env.VersionAtLeast("1.2.3")})
+
+	require.EqualError(t, environment.Parse(), "invalid value for VERSION: expected version to be at least 1.2.3 but got 1.2.3-rc.1+build.5")
+}
+
+func TestSemverErrors(t *testing.T) {
+	for _, text := range []string{"1.2", "1.2.x", "01.2.3", "1.2.3-"} {
+		var version env.Semver
+		require.Error(t, version.UnmarshalText([]byte(text)), text)
+	}
+}
+
+func TestSemverCompare(t *testing.T) {
+	ordered := []string{"1.0.0-alpha", "1.0.0-alpha.1", "1.0.0-alpha.beta", "1.0.0-beta", "1.0.0-beta.2", "1.0.0-beta.11", "1.0.0-rc.1", "1.0.0", "1.0.1", "1.1.0", "2.0.0"}
+
+	versions := make([]env.Semver, len(ordered))
+	for i, text := range ordered {
+		require.NoError(t, versions[i].UnmarshalText([]byte(text)))
+	}
+
+	for i := 1; i < len(versions); i++ {
+		require.Equal(t, -1, versions[i-1].Compare(versions[i]), "%s < %s", ordered[i-1], ordered[i])
+		require.Equal(t, 1, versions[i].Compare(versions[i-1]), "%s > %s", ordered[i], ordered[i-1])
+		require.Equal(t, 0, versions[i].Compare(versions[i]))
+	}
+}
@@ -0,0 +1,46 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Set is a set of values parsed from a comma separated list, such as ALLOWED_ORIGINS=a.com,b.com. Duplicates collapse.
+type Set[T comparable] map[T]struct{}
+
+// Contains reports whether value is in the set.
+func (s Set[T]) Contains(value T) bool {
+	_, ok := s[value]
+	return ok
+}
+
+// Len returns the number of values in the set.
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+func (s Set[T]) MarshalText() ([]byte, error) {
+	items := make([]string, 0, len(s))
+	for value := range s {
+		items = append(items, format(value))
+	}
+	sort.Strings(items)
+	return []byte(strings.Join(items, ",")), nil
+}
+
+func (s *Set[T]) UnmarshalText(text []byte) error {
+	set := Set[T]{}
+	if strings.TrimSpace(string(text)) != "" {
+		for _, elem := range strings.Split(string(text), ",") {
+			var value T
+			if err := parse(reflect.ValueOf(&value).Elem(), elem, flagOptions{}, false); err != nil {
+				return fmt.Errorf("failed to parse %q: %w", elem, err)
+			}
+			set[value] = struct{}{}
+		}
+	}
+	*s = set
+	return nil
+}
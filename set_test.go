@@ -0,0 +1,45 @@
+package env_test
+
+import (
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSet(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"ORIGINS": "a.com,b.com,a.com",
+			"PORTS":   "80,443",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		origins env.Set[string]
+		ports   env.Set[int]
+	)
+
+	env.FlagVar(environment, &origins, "ORIGINS")
+	env.FlagVar(environment, &ports, "PORTS")
+
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, 2, origins.Len())
+	require.True(t, origins.Contains("a.com"))
+	require.True(t, origins.Contains("b.com"))
+	require.False(t, origins.Contains("c.com"))
+
+	require.True(t, ports.Contains(443))
+	require.False(t, ports.Contains(8080))
+
+	text, err := origins.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "a.com,b.com", string(text))
+
+	environment = env.MakeEnvSet(func(string) (string, bool) { return "80,http", true })
+	env.FlagVar(environment, &ports, "PORTS")
+
+	require.EqualError(t, environment.Parse(), `failed to parse PORTS: failed to parse "http": strconv.ParseInt: parsing "http": invalid syntax`)
+}
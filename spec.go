@@ -0,0 +1,47 @@
+package env
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// VarSpec describes a variable to register with RegisterAll, for setups where the configuration is data-driven.
+// Ptr must be a non-nil pointer to the destination, and Default, when set, must be assignable to the type it points to.
+type VarSpec struct {
+	Name        string
+	Ptr         any
+	Required    bool
+	Default     any
+	Description string
+	Secret      bool
+}
+
+// RegisterAll registers every spec on the EnvSet. It stops at the first invalid spec, and the specs before it remain registered.
+func RegisterAll(envset EnvSet, specs []VarSpec) error {
+	for _, spec := range specs {
+		dst := reflect.ValueOf(spec.Ptr)
+		if dst.Kind() != reflect.Pointer || dst.IsNil() {
+			return fmt.Errorf("cannot register %s: expected a non-nil pointer but got %T", spec.Name, spec.Ptr)
+		}
+
+		fallback := spec.Default
+		if fallback != nil {
+			value := reflect.ValueOf(fallback)
+			if !value.Type().AssignableTo(dst.Type().Elem()) {
+				return fmt.Errorf("cannot register %s: default value of type %T is not assignable to %s", spec.Name, fallback, dst.Type().Elem())
+			}
+		}
+
+		envset.register(envset.prefix+spec.Name, flag{
+			value: reflectValue{dst},
+			opts: flagOptions{
+				required:    spec.Required,
+				fallback:    fallback,
+				description: spec.Description,
+				secret:      spec.Secret,
+			},
+		})
+	}
+
+	return nil
+}
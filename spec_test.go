@@ -0,0 +1,56 @@
+package env_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegisterAll(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"HOST":  "localhost",
+			"PORT":  "8080",
+			"HOSTS": "a,b",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		host    string
+		port    int
+		hosts   []string
+		timeout time.Duration
+		token   string
+	)
+
+	specs := []env.VarSpec{
+		{Name: "HOST", Ptr: &host},
+		{Name: "PORT", Ptr: &port, Required: true},
+		{Name: "HOSTS", Ptr: &hosts},
+		{Name: "TIMEOUT", Ptr: &timeout, Default: 5 * time.Second, Description: "request timeout"},
+		{Name: "TOKEN", Ptr: &token, Secret: true},
+	}
+
+	require.NoError(t, env.RegisterAll(environment, specs))
+	require.NoError(t, environment.Parse())
+
+	require.Equal(t, "localhost", host)
+	require.Equal(t, 8080, port)
+	require.Equal(t, []string{"a", "b"}, hosts)
+	require.Equal(t, 5*time.Second, timeout)
+	require.Equal(t, "", token)
+
+	require.EqualError(
+		t,
+		env.RegisterAll(environment, []env.VarSpec{{Name: "PORT", Ptr: port}}),
+		"cannot register PORT: expected a non-nil pointer but got int",
+	)
+	require.EqualError(
+		t,
+		env.RegisterAll(environment, []env.VarSpec{{Name: "PORT", Ptr: &port, Default: "80"}}),
+		"cannot register PORT: default value of type string is not assignable to int",
+	)
+}
@@ -0,0 +1,25 @@
+package env
+
+import (
+	stdflag "flag"
+)
+
+// StdFlagSet returns a lookup function over the flags of fs that were set on its command line, bridging code built on the
+// standard flag package. Only flags visited by fs.Visit are found, so that their defaults do not shadow other lookups.
+// A variable resolves the flag with its exact name, or else the flag named like with CommandLineArgs: DATABASE_URL resolves -database-url.
+// The flags are read on every lookup, so the lookup may be created before fs.Parse is called.
+func StdFlagSet(fs *stdflag.FlagSet) LookupFunc {
+	return func(name string) (string, bool) {
+		set := map[string]string{}
+		fs.Visit(func(f *stdflag.Flag) {
+			set[f.Name] = f.Value.String()
+		})
+
+		for _, candidate := range []string{name, argName(name)} {
+			if value, ok := set[candidate]; ok {
+				return value, true
+			}
+		}
+		return "", false
+	}
+}
@@ -0,0 +1,53 @@
+package env_test
+
+import (
+	"flag"
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStdFlagSet(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.Int("port", 8080, "")
+	fs.String("database-url", "", "")
+	fs.Bool("verbose", false, "")
+	fs.String("host", "localhost", "")
+
+	lookup := env.StdFlagSet(fs)
+
+	_, ok := lookup("PORT")
+	require.False(t, ok)
+
+	require.NoError(t, fs.Parse([]string{"-port=9090", "-database-url", "postgres://localhost/db", "-verbose"}))
+
+	for name, expected := range map[string]string{
+		"PORT":         "9090",
+		"DATABASE_URL": "postgres://localhost/db",
+		"verbose":      "true",
+	} {
+		value, ok := lookup(name)
+		require.True(t, ok, name)
+		require.Equal(t, expected, value, name)
+	}
+
+	_, ok = lookup("HOST")
+	require.False(t, ok)
+
+	var (
+		port int
+		host string
+	)
+
+	environment := env.MakeEnvSet(lookup, func(name string) (string, bool) {
+		value, ok := map[string]string{"HOST": "example.com", "PORT": "1"}[name]
+		return value, ok
+	})
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &host, "HOST")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, 9090, port)
+	require.Equal(t, "example.com", host)
+}
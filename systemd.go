@@ -0,0 +1,195 @@
+package env
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// SystemdEnvFile reads the file at path using the rules systemd applies to an EnvironmentFile
+// and returns a lookup over the variables it defines.
+//
+// Lines starting with # or ; are comments. Values may be single-quoted, in which case they are taken literally,
+// or double-quoted, in which case a backslash escapes ", \, ` and $. Outside of quotes a backslash escapes any character,
+// and a backslash at the end of a line continues the value on the next line. Unquoted trailing whitespace is dropped.
+func SystemdEnvFile(path string) (LookupFunc, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	vars, err := parseSystemdEnv(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return mapLookup(vars), nil
+}
+
+type systemdState int
+
+const (
+	systemdPreKey systemdState = iota
+	systemdKey
+	systemdPreValue
+	systemdValue
+	systemdValueEscape
+	systemdSingleQuote
+	systemdDoubleQuote
+	systemdDoubleQuoteEscape
+	systemdComment
+	systemdCommentEscape
+)
+
+func parseSystemdEnv(text string) (map[string]string, error) {
+	var (
+		vars  = map[string]string{}
+		state = systemdPreKey
+		line  = 1
+		key   strings.Builder
+		value strings.Builder
+
+		// keep marks the length of value that must survive trimming of trailing whitespace.
+		keep int
+	)
+
+	emit := func() error {
+		name := strings.TrimRight(key.String(), " \t\r")
+		if !isEnvName(name) {
+			return fmt.Errorf("line %d: invalid variable name %q", line, name)
+		}
+		vars[name] = value.String()[:keep]
+		key.Reset()
+		value.Reset()
+		keep = 0
+		return nil
+	}
+
+	for _, c := range text {
+		switch state {
+		case systemdPreKey:
+			switch {
+			case c == '#' || c == ';':
+				state = systemdComment
+			case !isSpace(c):
+				state = systemdKey
+				key.WriteRune(c)
+			}
+
+		case systemdKey:
+			switch c {
+			case '\n':
+				// lines without an assignment are ignored
+				key.Reset()
+				state = systemdPreKey
+			case '=':
+				state = systemdPreValue
+			default:
+				key.WriteRune(c)
+			}
+
+		case systemdPreValue, systemdValue:
+			switch {
+			case c == '\n':
+				if err := emit(); err != nil {
+					return nil, err
+				}
+				state = systemdPreKey
+			case state == systemdPreValue && c == '\'':
+				state = systemdSingleQuote
+			case state == systemdPreValue && c == '"':
+				state = systemdDoubleQuote
+			case c == '\\':
+				state = systemdValueEscape
+			case state == systemdPreValue && isSpace(c):
+				// skip leading whitespace
+			default:
+				state = systemdValue
+				value.WriteRune(c)
+				if !isSpace(c) {
+					keep = value.Len()
+				}
+			}
+
+		case systemdValueEscape:
+			state = systemdValue
+			if c != '\n' {
+				value.WriteRune(c)
+				keep = value.Len()
+			}
+
+		case systemdSingleQuote:
+			if c == '\'' {
+				state = systemdPreValue
+				break
+			}
+			value.WriteRune(c)
+			keep = value.Len()
+
+		case systemdDoubleQuote:
+			switch c {
+			case '"':
+				state = systemdPreValue
+			case '\\':
+				state = systemdDoubleQuoteEscape
+			default:
+				value.WriteRune(c)
+				keep = value.Len()
+			}
+
+		case systemdDoubleQuoteEscape:
+			state = systemdDoubleQuote
+			switch c {
+			case '"', '\\', '`', '$':
+				value.WriteRune(c)
+			case '\n':
+				// line continuation
+			default:
+				value.WriteByte('\\')
+				value.WriteRune(c)
+			}
+			keep = value.Len()
+
+		case systemdComment:
+			switch c {
+			case '\\':
+				state = systemdCommentEscape
+			case '\n':
+				state = systemdPreKey
+			}
+
+		case systemdCommentEscape:
+			state = systemdComment
+		}
+
+		if c == '\n' {
+			line++
+		}
+	}
+
+	switch state {
+	case systemdPreValue, systemdValue, systemdValueEscape, systemdSingleQuote, systemdDoubleQuote, systemdDoubleQuoteEscape:
+		// like systemd, a value that is still open at the end of the file is kept as is.
+		if err := emit(); err != nil {
+			return nil, err
+		}
+	}
+
+	return vars, nil
+}
+
+func isSpace(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\r' || c == '\n'
+}
+
+func isEnvName(name string) bool {
+	if name == "" || ('0' <= name[0] && name[0] <= '9') {
+		return false
+	}
+	for _, c := range name {
+		if c != '_' && !('a' <= c && c <= 'z') && !('A' <= c && c <= 'Z') && !('0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,66 @@
+package env_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdEnvFile(t *testing.T) {
+	content := `# database settings
+; also a comment \
+  continued comment=ignored
+DATABASE_URL=postgres://localhost:5432/db
+NAME = 'single quoted \ value'
+GREETING="say \"hello\" to \$USER\n"
+HOSTS=one \
+two \
+three
+PADDED=  value with trailing space   
+EMPTY=
+ESCAPED=a\ b\\c
+MULTILINE="first \
+second"
+NO_ASSIGNMENT
+`
+
+	path := filepath.Join(t.TempDir(), "service.env")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+
+	lookup, err := env.SystemdEnvFile(path)
+	require.NoError(t, err)
+
+	for name, expected := range map[string]string{
+		"DATABASE_URL": "postgres://localhost:5432/db",
+		"NAME":         `single quoted \ value`,
+		"GREETING":     `say "hello" to $USER\n`,
+		"HOSTS":        "one two three",
+		"PADDED":       "value with trailing space",
+		"EMPTY":        "",
+		"ESCAPED":      `a b\c`,
+		"MULTILINE":    "first second",
+	} {
+		value, ok := lookup(name)
+		require.True(t, ok, name)
+		require.Equal(t, expected, value, name)
+	}
+
+	for _, name := range []string{"NO_ASSIGNMENT", "continued comment"} {
+		_, ok := lookup(name)
+		require.False(t, ok, name)
+	}
+}
+
+func TestSystemdEnvFileErrors(t *testing.T) {
+	_, err := env.SystemdEnvFile(filepath.Join(t.TempDir(), "missing.env"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	path := filepath.Join(t.TempDir(), "invalid.env")
+	require.NoError(t, os.WriteFile(path, []byte("VALID=1\nNOT-VALID=2\n"), 0o644))
+
+	_, err = env.SystemdEnvFile(path)
+	require.EqualError(t, err, path+`: line 2: invalid variable name "NOT-VALID"`)
+}
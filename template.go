@@ -0,0 +1,31 @@
+package env
+
+import "strings"
+
+// GenerateEnvTemplate renders a sample .env file listing every variable registered on the EnvSet, sorted by name.
+// Each variable is assigned its default value, and its description and whether it is required are written as comments above it.
+// The defaults of Secret variables are left out.
+func GenerateEnvTemplate(envset EnvSet) string {
+	var builder strings.Builder
+	for i, name := range envset.Names() {
+		if i > 0 {
+			builder.WriteString("\n")
+		}
+
+		opts := envset.flags[name].opts
+		if opts.description != "" {
+			builder.WriteString("# " + opts.description + "\n")
+		}
+		if opts.required {
+			builder.WriteString("# required\n")
+		}
+
+		builder.WriteString(name + "=")
+		if !opts.required && !opts.secret {
+			builder.WriteString(format(opts.fallback))
+		}
+		builder.WriteString("\n")
+	}
+
+	return builder.String()
+}
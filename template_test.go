@@ -0,0 +1,66 @@
+package env_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGenerateEnvTemplate(t *testing.T) {
+	environment := env.MakeEnvSet()
+
+	var (
+		databaseURL string
+		port        int
+		timeout     time.Duration
+		hosts       []string
+		labels      map[string]string
+		debug       bool
+	)
+
+	env.FlagVar(environment, &databaseURL, "DATABASE_URL", env.Options[string]{Required: true, Description: "postgres connection string"})
+	env.FlagVar(environment, &port, "PORT", env.Options[int]{DefaultValue: 8080})
+	env.FlagVar(environment, &timeout, "TIMEOUT", env.Options[time.Duration]{DefaultValue: 5 * time.Second, Description: "request timeout"})
+	env.FlagVar(environment, &hosts, "HOSTS", env.Options[[]string]{DefaultValue: []string{"a", "b"}})
+	env.FlagVar(environment, &labels, "LABELS", env.Options[map[string]string]{DefaultValue: map[string]string{"z": "1", "a": "2"}})
+	env.FlagVar(environment, &debug, "DEBUG")
+
+	expected := `# postgres connection string
+# required
+DATABASE_URL=
+
+DEBUG=false
+
+HOSTS=a,b
+
+LABELS=a=2,z=1
+
+PORT=8080
+
+# request timeout
+TIMEOUT=5s
+`
+
+	require.Equal(t, expected, env.GenerateEnvTemplate(environment))
+}
+
+func TestGenerateEnvTemplateSecret(t *testing.T) {
+	environment := env.MakeEnvSet()
+
+	var password, token string
+
+	env.FlagVar(environment, &password, "PASSWORD", env.Options[string]{DefaultValue: "hunter2", Secret: true})
+	require.NoError(t, env.RegisterAll(environment, []env.VarSpec{
+		{Name: "TOKEN", Ptr: &token, Default: "s3cr3t", Secret: true, Description: "api token"},
+	}))
+
+	expected := `PASSWORD=
+
+# api token
+TOKEN=
+`
+
+	require.Equal(t, expected, env.GenerateEnvTemplate(environment))
+}
@@ -0,0 +1,52 @@
+package env
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Timeouts groups the timeouts of a connection, parsed from comma separated key=duration pairs
+// such as connect=5s,read=10s,write=10s. Omitted timeouts keep their current value, so that defaults can be set beforehand.
+type Timeouts struct {
+	Connect time.Duration
+	Read    time.Duration
+	Write   time.Duration
+}
+
+func (t Timeouts) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("connect=%s,read=%s,write=%s", t.Connect, t.Read, t.Write)), nil
+}
+
+func (t *Timeouts) UnmarshalText(text []byte) error {
+	if strings.TrimSpace(string(text)) == "" {
+		return nil
+	}
+
+	for _, elem := range strings.Split(string(text), ",") {
+		key, value, ok := strings.Cut(elem, "=")
+		if !ok {
+			return fmt.Errorf("expected key=duration but got %q", elem)
+		}
+
+		var dst *time.Duration
+		switch key = strings.TrimSpace(key); strings.ToLower(key) {
+		case "connect":
+			dst = &t.Connect
+		case "read":
+			dst = &t.Read
+		case "write":
+			dst = &t.Write
+		default:
+			return fmt.Errorf("unknown timeout %q: expected connect, read or write", key)
+		}
+
+		d, err := time.ParseDuration(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid %s timeout: %w", key, err)
+		}
+		*dst = d
+	}
+
+	return nil
+}
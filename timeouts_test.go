@@ -0,0 +1,49 @@
+package env_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTimeouts(t *testing.T) {
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := map[string]string{
+			"HTTP_TIMEOUTS": "connect=5s,read=10s,write=10s",
+			"DB_TIMEOUTS":   "connect=2s, read=1m",
+		}[name]
+		return value, ok
+	})
+
+	var (
+		httpTimeouts env.Timeouts
+		dbTimeouts   = env.Timeouts{Connect: time.Second, Read: time.Second, Write: 30 * time.Second}
+	)
+
+	env.FlagVar(environment, &httpTimeouts, "HTTP_TIMEOUTS")
+	env.FlagVar(environment, &dbTimeouts, "DB_TIMEOUTS")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, env.Timeouts{Connect: 5 * time.Second, Read: 10 * time.Second, Write: 10 * time.Second}, httpTimeouts)
+	require.Equal(t, env.Timeouts{Connect: 2 * time.Second, Read: time.Minute, Write: 30 * time.Second}, dbTimeouts)
+
+	text, err := dbTimeouts.MarshalText()
+	require.NoError(t, err)
+	require.Equal(t, "connect=2s,read=1m0s,write=30s", string(text))
+}
+
+func TestTimeoutsErrors(t *testing.T) {
+	for _, tc := range []struct {
+		Value string
+		Error string
+	}{
+		{Value: "connect", Error: `expected key=duration but got "connect"`},
+		{Value: "idle=5s", Error: `unknown timeout "idle": expected connect, read or write`},
+		{Value: "read=soon", Error: `invalid read timeout: time: invalid duration "soon"`},
+	} {
+		var timeouts env.Timeouts
+		require.EqualError(t, timeouts.UnmarshalText([]byte(tc.Value)), tc.Error)
+	}
+}
@@ -0,0 +1,54 @@
+package env
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// VarTLS registers the required variables certName and keyName, and sets p to a tls.Config holding the certificate they form.
+// Each variable holds either PEM encoded data or the path of a file containing it. The key variable is secret,
+// and errors loading the pair never include its contents.
+func VarTLS(envset EnvSet, p **tls.Config, certName, keyName string) {
+	var cert, key string
+
+	FlagVar(envset, &cert, certName, Options[string]{Required: true})
+	FlagVar(envset, &key, keyName, Options[string]{Required: true, Secret: true})
+
+	certName, keyName = envset.prefix+certName, envset.prefix+keyName
+
+	*envset.finalizers = append(*envset.finalizers, func() error {
+		certPEM, err := readPEM(cert)
+		if err != nil {
+			return fmt.Errorf("failed to load certificate %s: %w", certName, err)
+		}
+		keyPEM, err := readPEM(key)
+		if err != nil {
+			return fmt.Errorf("failed to load key %s: %w", keyName, err)
+		}
+
+		pair, err := tls.X509KeyPair(certPEM, keyPEM)
+		if err != nil {
+			return fmt.Errorf("failed to load key pair %s and %s: %w", certName, keyName, err)
+		}
+
+		*p = &tls.Config{Certificates: []tls.Certificate{pair}}
+		return nil
+	})
+}
+
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	data, err := os.ReadFile(value)
+	if err != nil {
+		// Strip the path error down to its cause: the value is not PEM, but might still be key material.
+		if pathErr, ok := err.(*os.PathError); ok {
+			return nil, fmt.Errorf("failed to read file: %w", pathErr.Err)
+		}
+		return nil, err
+	}
+	return data, nil
+}
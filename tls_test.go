@@ -0,0 +1,111 @@
+package env_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func selfSignedPair(t *testing.T) (certPEM, keyPEM string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certPEM = string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}))
+	keyPEM = string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}))
+	return certPEM, keyPEM
+}
+
+func TestVarTLS(t *testing.T) {
+	certPEM, keyPEM := selfSignedPair(t)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "tls.crt")
+	keyFile := filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, []byte(certPEM), 0o644))
+	require.NoError(t, os.WriteFile(keyFile, []byte(keyPEM), 0o600))
+
+	for name, values := range map[string]map[string]string{
+		"pem":   {"TLS_CERT": certPEM, "TLS_KEY": keyPEM},
+		"files": {"TLS_CERT": certFile, "TLS_KEY": keyFile},
+		"mixed": {"TLS_CERT": certFile, "TLS_KEY": keyPEM},
+	} {
+		t.Run(name, func(t *testing.T) {
+			environment := env.MakeEnvSet(env.LookupFunc(func(key string) (string, bool) {
+				value, ok := values[key]
+				return value, ok
+			}))
+
+			var config *tls.Config
+			env.VarTLS(environment, &config, "TLS_CERT", "TLS_KEY")
+
+			require.NoError(t, environment.Parse())
+			require.NotNil(t, config)
+			require.Len(t, config.Certificates, 1)
+
+			leaf, err := x509.ParseCertificate(config.Certificates[0].Certificate[0])
+			require.NoError(t, err)
+			require.Equal(t, "localhost", leaf.Subject.CommonName)
+		})
+	}
+}
+
+func TestVarTLSErrors(t *testing.T) {
+	certPEM, keyPEM := selfSignedPair(t)
+	_, otherKeyPEM := selfSignedPair(t)
+
+	parse := func(values map[string]string) (*tls.Config, error) {
+		environment := env.MakeEnvSet(env.LookupFunc(func(key string) (string, bool) {
+			value, ok := values[key]
+			return value, ok
+		}))
+
+		var config *tls.Config
+		env.VarTLS(environment, &config, "TLS_CERT", "TLS_KEY")
+
+		return config, environment.Parse()
+	}
+
+	config, err := parse(map[string]string{"TLS_CERT": certPEM})
+	require.EqualError(t, err, `"TLS_KEY" is required but not found`)
+	require.Nil(t, config)
+
+	config, err = parse(map[string]string{"TLS_CERT": certPEM, "TLS_KEY": otherKeyPEM})
+	require.EqualError(t, err, "failed to load key pair TLS_CERT and TLS_KEY: tls: private key does not match public key")
+	require.Nil(t, config)
+	require.NotContains(t, err.Error(), "PRIVATE KEY")
+
+	config, err = parse(map[string]string{"TLS_CERT": certPEM, "TLS_KEY": "/does/not/exist"})
+	require.EqualError(t, err, "failed to load key TLS_KEY: failed to read file: no such file or directory")
+	require.Nil(t, config)
+
+	config, err = parse(map[string]string{"TLS_CERT": keyPEM, "TLS_KEY": keyPEM})
+	require.Error(t, err)
+	require.NotContains(t, err.Error(), keyPEM)
+	require.Nil(t, config)
+}
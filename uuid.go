@@ -0,0 +1,10 @@
+package env
+
+import "github.com/google/uuid"
+
+// VarUUID registers a variable whose UUID value is stored in p. Any form accepted by uuid.Parse is valid,
+// such as 6ba7b810-9dad-11d1-80b4-00c04fd430c8 or its urn:uuid: and braced variants.
+// It is equivalent to FlagVar, as uuid.UUID implements encoding.TextUnmarshaler, and so are slices of UUIDs registered with FlagVar.
+func VarUUID(envset EnvSet, p *uuid.UUID, name string, opts ...Options[uuid.UUID]) {
+	FlagVar(envset, p, name, opts...)
+}
@@ -0,0 +1,206 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Contains returns a validator that requires the value to contain sub.
+func Contains(sub string) func(string) error {
+	return func(value string) error {
+		if !strings.Contains(value, sub) {
+			return fmt.Errorf("expected value to contain %q but got %q", sub, value)
+		}
+		return nil
+	}
+}
+
+// HasPrefix returns a validator that requires the value to start with prefix.
+func HasPrefix(prefix string) func(string) error {
+	return func(value string) error {
+		if !strings.HasPrefix(value, prefix) {
+			return fmt.Errorf("expected value to have prefix %q but got %q", prefix, value)
+		}
+		return nil
+	}
+}
+
+// HasSuffix returns a validator that requires the value to end with suffix.
+func HasSuffix(suffix string) func(string) error {
+	return func(value string) error {
+		if !strings.HasSuffix(value, suffix) {
+			return fmt.Errorf("expected value to have suffix %q but got %q", suffix, value)
+		}
+		return nil
+	}
+}
+
+// OneOfStrings returns a validator that requires the value to be one of allowed.
+func OneOfStrings(allowed ...string) func(string) error {
+	return func(value string) error {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected value to be one of %q but got %q", allowed, value)
+	}
+}
+
+// FileExists returns a validator that requires the value to be the path of an existing regular file.
+func FileExists() func(string) error {
+	return func(path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("file %q does not exist", path)
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return fmt.Errorf("expected %q to be a file but it is a %s", path, fileKind(info))
+		}
+		return nil
+	}
+}
+
+// DirExists returns a validator that requires the value to be the path of an existing directory.
+func DirExists() func(string) error {
+	return func(path string) error {
+		info, err := os.Stat(path)
+		if err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return fmt.Errorf("directory %q does not exist", path)
+			}
+			return err
+		}
+		if !info.IsDir() {
+			return fmt.Errorf("expected %q to be a directory but it is a %s", path, fileKind(info))
+		}
+		return nil
+	}
+}
+
+func fileKind(info os.FileInfo) string {
+	switch mode := info.Mode(); {
+	case mode.IsDir():
+		return "directory"
+	case mode.IsRegular():
+		return "file"
+	default:
+		return mode.Type().String() + " file"
+	}
+}
+
+// StringEnum returns a validator that requires the value to be one of allowed, for string-backed enum types such as type Env string.
+func StringEnum[T ~string](allowed ...T) func(T) error {
+	return func(value T) error {
+		for _, candidate := range allowed {
+			if value == candidate {
+				return nil
+			}
+		}
+		return fmt.Errorf("expected value to be one of %q but got %q", allowed, value)
+	}
+}
+
+// DurationRange returns a validator that requires every duration of the list to be between min and max inclusive.
+func DurationRange(min, max time.Duration) func([]time.Duration) error {
+	return func(durations []time.Duration) error {
+		for i, duration := range durations {
+			if duration < min || duration > max {
+				return fmt.Errorf("expected duration at index %d to be between %v and %v but got %v", i, min, max, duration)
+			}
+		}
+		return nil
+	}
+}
+
+// parseValidateTag builds a validator from the rules of a validate struct tag for values of type t.
+func parseValidateTag(tag string, t reflect.Type) (func(any) error, error) {
+	var validators []func(reflect.Value) error
+
+	for _, rule := range strings.Split(tag, ",") {
+		key, arg, _ := strings.Cut(strings.TrimSpace(rule), "=")
+
+		var validate func(reflect.Value) error
+		switch key {
+		case "min", "max":
+			bound, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s rule: %w", key, err)
+			}
+			validate, err = boundValidator(key, bound, t)
+			if err != nil {
+				return nil, err
+			}
+		case "oneof":
+			validate = stringValidator(OneOfStrings(strings.Fields(arg)...))
+		case "contains":
+			validate = stringValidator(Contains(arg))
+		case "prefix":
+			validate = stringValidator(HasPrefix(arg))
+		case "suffix":
+			validate = stringValidator(HasSuffix(arg))
+		default:
+			return nil, fmt.Errorf("unknown validation rule %q", key)
+		}
+
+		if key != "min" && key != "max" && t.Kind() != reflect.String {
+			return nil, fmt.Errorf("%s rule requires a string but got %s", key, t)
+		}
+
+		validators = append(validators, validate)
+	}
+
+	return func(value any) error {
+		v := reflect.ValueOf(value)
+		for _, validate := range validators {
+			if err := validate(v); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+func stringValidator(fn func(string) error) func(reflect.Value) error {
+	return func(v reflect.Value) error { return fn(v.String()) }
+}
+
+func boundValidator(key string, bound float64, t reflect.Type) (func(reflect.Value) error, error) {
+	var (
+		measure func(reflect.Value) float64
+		subject = "value"
+	)
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		measure = func(v reflect.Value) float64 { return float64(v.Int()) }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		measure = func(v reflect.Value) float64 { return float64(v.Uint()) }
+	case reflect.Float32, reflect.Float64:
+		measure = func(v reflect.Value) float64 { return v.Float() }
+	case reflect.String, reflect.Slice, reflect.Map:
+		measure = func(v reflect.Value) float64 { return float64(v.Len()) }
+		subject = "length"
+	default:
+		return nil, fmt.Errorf("%s rule is not supported for %s", key, t)
+	}
+
+	return func(v reflect.Value) error {
+		actual := measure(v)
+		if key == "min" && actual < bound {
+			return fmt.Errorf("expected %s to be at least %v but got %v", subject, bound, actual)
+		}
+		if key == "max" && actual > bound {
+			return fmt.Errorf("expected %s to be at most %v but got %v", subject, bound, actual)
+		}
+		return nil
+	}, nil
+}
@@ -0,0 +1,125 @@
+package env_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStringValidators(t *testing.T) {
+	testCases := []struct {
+		Name      string
+		Validator func(string) error
+		Valid     string
+		Invalid   string
+		Error     string
+	}{
+		{
+			Name:      "contains",
+			Validator: env.Contains("@"),
+			Valid:     "ops@example.com",
+			Invalid:   "ops",
+			Error:     `expected value to contain "@" but got "ops"`,
+		},
+		{
+			Name:      "prefix",
+			Validator: env.HasPrefix("https://"),
+			Valid:     "https://example.com",
+			Invalid:   "http://example.com",
+			Error:     `expected value to have prefix "https://" but got "http://example.com"`,
+		},
+		{
+			Name:      "suffix",
+			Validator: env.HasSuffix(".sql"),
+			Valid:     "schema.sql",
+			Invalid:   "schema.txt",
+			Error:     `expected value to have suffix ".sql" but got "schema.txt"`,
+		},
+		{
+			Name:      "one of",
+			Validator: env.OneOfStrings("debug", "info"),
+			Valid:     "info",
+			Invalid:   "trace",
+			Error:     `expected value to be one of ["debug" "info"] but got "trace"`,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.Name, func(t *testing.T) {
+			require.NoError(t, tc.Validator(tc.Valid))
+			require.EqualError(t, tc.Validator(tc.Invalid), tc.Error)
+		})
+	}
+}
+
+func TestValidateOption(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "trace", true })
+
+	var level string
+	env.FlagVar(environment, &level, "LEVEL", env.Options[string]{Validate: env.OneOfStrings("debug", "info")})
+
+	require.EqualError(t, environment.Parse(), `invalid value for LEVEL: expected value to be one of ["debug" "info"] but got "trace"`)
+	require.EqualError(t, environment.Validate(), `invalid value for LEVEL: expected value to be one of ["debug" "info"] but got "trace"`)
+}
+
+func TestDurationRange(t *testing.T) {
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "1s,5m,2h", true })
+
+	var timeouts []time.Duration
+	env.FlagVar(environment, &timeouts, "TIMEOUTS", env.Options[[]time.Duration]{
+		Validate: env.DurationRange(time.Second, time.Hour),
+	})
+
+	require.EqualError(t, environment.Parse(), "invalid value for TIMEOUTS: expected duration at index 2 to be between 1s and 1h0m0s but got 2h0m0s")
+
+	env.FlagVar(environment, &timeouts, "TIMEOUTS", env.Options[[]time.Duration]{
+		Validate: env.DurationRange(time.Second, 2*time.Hour),
+	})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, []time.Duration{time.Second, 5 * time.Minute, 2 * time.Hour}, timeouts)
+}
+
+func TestStringEnum(t *testing.T) {
+	type Env string
+
+	const (
+		Dev  Env = "dev"
+		Prod Env = "prod"
+	)
+
+	environment := env.MakeEnvSet(func(string) (string, bool) { return "staging", true })
+
+	var value Env
+	env.FlagVar(environment, &value, "ENV", env.Options[Env]{Validate: env.StringEnum(Dev, Prod)})
+
+	require.EqualError(t, environment.Parse(), `invalid value for ENV: expected value to be one of ["dev" "prod"] but got "staging"`)
+
+	environment = env.MakeEnvSet(func(string) (string, bool) { return "prod", true })
+	env.FlagVar(environment, &value, "ENV", env.Options[Env]{Validate: env.StringEnum(Dev, Prod)})
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, Prod, value)
+}
+
+func TestPathValidators(t *testing.T) {
+	dir := t.TempDir()
+
+	file := filepath.Join(dir, "config.yaml")
+	require.NoError(t, os.WriteFile(file, []byte("{}"), 0o644))
+
+	missing := filepath.Join(dir, "missing")
+
+	require.NoError(t, env.FileExists()(file))
+	require.EqualError(t, env.FileExists()(dir), fmt.Sprintf("expected %q to be a file but it is a directory", dir))
+	require.EqualError(t, env.FileExists()(missing), fmt.Sprintf("file %q does not exist", missing))
+
+	require.NoError(t, env.DirExists()(dir))
+	require.EqualError(t, env.DirExists()(file), fmt.Sprintf("expected %q to be a directory but it is a file", file))
+	require.EqualError(t, env.DirExists()(missing), fmt.Sprintf("directory %q does not exist", missing))
+}
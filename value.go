@@ -2,37 +2,239 @@ package env
 
 import (
 	"encoding"
+	"encoding/base64"
+	stdflag "flag"
 	"fmt"
+	"net/url"
+	"os"
 	"reflect"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
-	"time"
+	"unicode"
+	"unicode/utf8"
 )
 
 type value interface {
-	Parse(string) error
+	Parse(string, flagOptions) error
 	Set(any)
+	Get() any
+	Type() reflect.Type
+	// scratch returns a value backed by a copy of the destination so that it can be parsed without side effects.
+	scratch() value
 }
 
 type genericValue[T any] struct{ dst *T }
 
 func (v genericValue[T]) Set(value any) {
-	*v.dst = value.(T)
+	// value is a nil interface when the default of an interface type is not set, in which case the zero value is used.
+	*v.dst, _ = value.(T)
 }
 
-func (v genericValue[T]) Parse(envvar string) (err error) {
-	return parse(reflect.ValueOf(v.dst), envvar, true)
+func (v genericValue[T]) Get() any {
+	return *v.dst
 }
 
-func parse(v reflect.Value, text string, topLevel bool) error {
-	if unmarshaler, ok := v.Interface().(encoding.TextUnmarshaler); ok {
-		return unmarshaler.UnmarshalText([]byte(text))
+func (v genericValue[T]) Type() reflect.Type {
+	return reflect.TypeOf(v.dst).Elem()
+}
+
+func (v genericValue[T]) scratch() value {
+	dst := new(T)
+	reflect.ValueOf(dst).Elem().Set(copyPointers(reflect.ValueOf(v.dst).Elem()))
+	return genericValue[T]{dst}
+}
+
+func (v genericValue[T]) Parse(envvar string, opts flagOptions) (err error) {
+	return parse(reflect.ValueOf(v.dst), envvar, opts, true)
+}
+
+// reflectValue is a value for destinations whose type is only known at runtime, such as struct fields.
+// dst must be a pointer.
+type reflectValue struct{ dst reflect.Value }
+
+func (v reflectValue) Set(value any) {
+	if value == nil {
+		v.dst.Elem().Set(reflect.Zero(v.dst.Elem().Type()))
+		return
+	}
+	v.dst.Elem().Set(reflect.ValueOf(value))
+}
+
+func (v reflectValue) Get() any {
+	return v.dst.Elem().Interface()
+}
+
+func (v reflectValue) Type() reflect.Type {
+	return v.dst.Type().Elem()
+}
+
+func (v reflectValue) scratch() value {
+	dst := reflect.New(v.dst.Elem().Type())
+	dst.Elem().Set(copyPointers(v.dst.Elem()))
+	return reflectValue{dst}
+}
+
+// copyPointers returns a copy of v where every level of non-nil pointers points to newly allocated values,
+// since parse writes through the pointers it finds rather than replacing them.
+func copyPointers(v reflect.Value) reflect.Value {
+	if v.Kind() != reflect.Pointer || v.IsNil() {
+		return v
+	}
+	dst := reflect.New(v.Type().Elem())
+	dst.Elem().Set(copyPointers(v.Elem()))
+	return dst
+}
+
+func (v reflectValue) Parse(text string, opts flagOptions) error {
+	return parse(v.dst, text, opts, true)
+}
+
+// choiceValue is a value that resolves its text to one of a fixed set of choices by name.
+type choiceValue[T any] struct {
+	dst     *T
+	choices map[string]T
+}
+
+func (v choiceValue[T]) Set(value any) {
+	*v.dst, _ = value.(T)
+}
+
+func (v choiceValue[T]) Get() any {
+	return *v.dst
+}
+
+func (v choiceValue[T]) Type() reflect.Type {
+	return reflect.TypeOf(v.dst).Elem()
+}
+
+func (v choiceValue[T]) scratch() value {
+	dst := new(T)
+	*dst = *v.dst
+	return choiceValue[T]{dst, v.choices}
+}
+
+func (v choiceValue[T]) Parse(text string, _ flagOptions) error {
+	choice, ok := v.choices[text]
+	if !ok {
+		keys := make([]string, 0, len(v.choices))
+		for key := range v.choices {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		return fmt.Errorf("expected one of %q but got %q", keys, text)
+	}
+	*v.dst = choice
+	return nil
+}
+
+// parseQueryMap parses text as a URL query string into the map v.
+// When a key is repeated all of its values are kept if the map's values are slices, otherwise the first value is used.
+func parseQueryMap(v reflect.Value, text string, opts flagOptions) error {
+	query, err := url.ParseQuery(text)
+	if err != nil {
+		return err
+	}
+
+	t := v.Type()
+	target := reflect.MakeMapWithSize(t, len(query))
+
+	for key, values := range query {
+		if opts.keyTransform != nil {
+			key = opts.keyTransform(key)
+		}
+		k := reflect.New(t.Key()).Elem()
+		if err := parse(k, key, opts, false); err != nil {
+			return fmt.Errorf("failed to parse key: %s: %w", key, err)
+		}
+
+		elem := reflect.New(t.Elem()).Elem()
+		if isCollection(t.Elem()) && t.Elem().Kind() == reflect.Slice {
+			elem.Set(reflect.MakeSlice(t.Elem(), len(values), len(values)))
+			for i, value := range values {
+				if err := parse(elem.Index(i), value, opts, false); err != nil {
+					return fmt.Errorf("failed to parse value at key: %s: %w", key, err)
+				}
+			}
+		} else if err := parse(elem, values[0], opts, false); err != nil {
+			return fmt.Errorf("failed to parse value at key: %s: %w", key, err)
+		}
+
+		target.SetMapIndex(k, elem)
 	}
 
-	if unmarshaler, ok := v.Interface().(encoding.BinaryUnmarshaler); ok {
-		return unmarshaler.UnmarshalBinary([]byte(text))
+	v.Set(target)
+	return nil
+}
+
+var textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+
+func isTextUnmarshaler(t reflect.Type) bool {
+	return t.Implements(textUnmarshalerType) || reflect.PointerTo(t).Implements(textUnmarshalerType)
+}
+
+// unmarshalerTypes are the interfaces through which parse lets a type parse itself.
+var unmarshalerTypes = []reflect.Type{
+	textUnmarshalerType,
+	reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem(),
+	reflect.TypeOf((*stdflag.Value)(nil)).Elem(),
+}
+
+// isUnmarshaler reports whether t, or a pointer to t, implements any of the interfaces through which parse lets a type parse itself.
+func isUnmarshaler(t reflect.Type) bool {
+	for _, iface := range unmarshalerTypes {
+		if t.Implements(iface) || reflect.PointerTo(t).Implements(iface) {
+			return true
+		}
 	}
+	return false
+}
+
+// splitNested splits text around separator, except where the separator is within brackets, braces or a double-quoted string.
+// This keeps items such as JSON documents intact.
+func splitNested(text, separator string) []string {
+	var (
+		items   []string
+		depth   int
+		quoted  bool
+		escaped bool
+		start   int
+	)
 
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		switch {
+		case escaped:
+			escaped = false
+		case quoted && c == '\\':
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case quoted:
+		case c == '[' || c == '{' || c == '(':
+			depth++
+		case c == ']' || c == '}' || c == ')':
+			depth--
+		case depth == 0 && strings.HasPrefix(text[i:], separator):
+			items = append(items, text[start:i])
+			start = i + len(separator)
+			i += len(separator) - 1
+		}
+	}
+
+	return append(items, text[start:])
+}
+
+// isCollection reports whether values of type t are parsed from a list of items, ie: slices other than []byte and maps.
+func isCollection(t reflect.Type) bool {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Map || (t.Kind() == reflect.Slice && t.Elem().Kind() != reflect.Uint8)
+}
+
+func parse(v reflect.Value, text string, opts flagOptions, topLevel bool) error {
 	t := v.Type()
 
 	for t.Kind() == reflect.Pointer {
@@ -43,19 +245,35 @@ func parse(v reflect.Value, text string, topLevel bool) error {
 		v = v.Elem()
 	}
 
+	// Unmarshalers are generally implemented on pointer receivers, so they must be looked up on the address of the value.
+	// This matters for slice elements and map entries, which are not reached through a pointer.
+	if v.CanAddr() {
+		target := v.Addr().Interface()
+
+		if unmarshaler, ok := target.(encoding.TextUnmarshaler); ok {
+			return unmarshaler.UnmarshalText([]byte(text))
+		}
+
+		if unmarshaler, ok := target.(encoding.BinaryUnmarshaler); ok {
+			return unmarshaler.UnmarshalBinary([]byte(text))
+		}
+
+		if setter, ok := target.(stdflag.Value); ok {
+			return setter.Set(text)
+		}
+	}
+
+	if decode, ok := lookupDecoder(t); ok {
+		return decode(text, v, opts)
+	}
+
 	switch t.Kind() {
 	case reflect.String:
 		v.SetString(text)
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-		if v.Kind() == reflect.Int64 && t.PkgPath() == "time" && t.Name() == "Duration" {
-			d, err := time.ParseDuration(text)
-			if err != nil {
-				return err
-			}
-			v.SetInt(int64(d))
-			break
+		if topLevel {
+			text = stripThousandsSeparator(text, opts)
 		}
-
 		val, err := strconv.ParseInt(text, 0, t.Bits())
 		if err != nil {
 			return err
@@ -63,41 +281,91 @@ func parse(v reflect.Value, text string, topLevel bool) error {
 		v.SetInt(val)
 
 	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if topLevel {
+			text = stripThousandsSeparator(text, opts)
+		}
 		val, err := strconv.ParseUint(text, 0, t.Bits())
 		if err != nil {
 			return err
 		}
 		v.SetUint(val)
 	case reflect.Bool:
+		if opts.presenceIsTrue && strings.TrimSpace(text) == "" {
+			v.SetBool(true)
+			break
+		}
 		val, err := strconv.ParseBool(text)
 		if err != nil {
 			return err
 		}
 		v.SetBool(val)
 	case reflect.Float32, reflect.Float64:
+		if topLevel {
+			text = stripThousandsSeparator(text, opts)
+		}
 		val, err := strconv.ParseFloat(text, t.Bits())
 		if err != nil {
 			return err
 		}
 		v.SetFloat(val)
 	case reflect.Slice:
+		// Elements that unmarshal themselves may contain separators, so they are split with care for nesting
+		// and, when the option is set, may be nested one level deeper inside brackets.
+		nested := opts.nestedUnmarshalers && (isTextUnmarshaler(t.Elem()) ||
+			topLevel && t.Elem().Kind() == reflect.Slice && isTextUnmarshaler(t.Elem().Elem()))
+
 		if !topLevel {
-			return fmt.Errorf("cannot support deep slices")
+			if !nested {
+				return fmt.Errorf("cannot support deep slices")
+			}
+			text = strings.TrimSpace(text)
+			if strings.HasPrefix(text, "[") && strings.HasSuffix(text, "]") {
+				text = text[1 : len(text)-1]
+			}
 		}
 
 		if t.Elem().Kind() == reflect.Uint8 {
-			v.Set(reflect.ValueOf([]byte(text)))
+			data := []byte(text)
+			if opts.fromFile {
+				var err error
+				if data, err = os.ReadFile(text); err != nil {
+					return err
+				}
+			}
+			if opts.autoDecode {
+				data = autoDecodeBase64(data)
+			}
+			v.Set(reflect.ValueOf(data))
 			break
 		}
 
+		separator := opts.listSeparator()
+		if opts.separatorPrefix {
+			separator, text = cutSeparatorPrefix(text, separator)
+		}
+
 		if strings.TrimSpace(text) == "" {
 			v.Set(reflect.MakeSlice(t, 0, 0))
+			break
+		}
+
+		items := strings.Split(text, separator)
+		if nested {
+			items = splitNested(text, separator)
+		}
+		if opts.skipEmpty {
+			nonEmpty := items[:0]
+			for _, item := range items {
+				if strings.TrimSpace(item) != "" {
+					nonEmpty = append(nonEmpty, item)
+				}
+			}
+			items = nonEmpty
 		}
 
-		items := strings.Split(text, ",")
 		slice := reflect.MakeSlice(t, len(items), len(items))
 		for i, subtext := range items {
-			err := parse(slice.Index(i), subtext, false)
+			err := parse(slice.Index(i), subtext, opts, false)
 			if err != nil {
 				return err
 			}
@@ -115,19 +383,31 @@ func parse(v reflect.Value, text string, topLevel bool) error {
 			return nil
 		}
 
+		if opts.queryString {
+			return parseQueryMap(v, text, opts)
+		}
+
 		target := reflect.MakeMap(t)
-		for _, elem := range strings.Split(text, ",") {
-			key, value, ok := strings.Cut(elem, "=")
+		keyValueSeparator := "="
+		if opts.keyValueSeparator != "" {
+			keyValueSeparator = opts.keyValueSeparator
+		}
+
+		for _, elem := range strings.Split(text, opts.listSeparator()) {
+			key, value, ok := strings.Cut(elem, keyValueSeparator)
 			if !ok {
 				continue
 			}
+			if opts.keyTransform != nil {
+				key = opts.keyTransform(key)
+			}
 			k := reflect.New(t.Key()).Elem()
-			if err := parse(k, key, false); err != nil {
+			if err := parse(k, key, opts, false); err != nil {
 				return fmt.Errorf("failed to parse key: %s: %w", key, err)
 			}
 
 			v := reflect.New(t.Elem()).Elem()
-			if err := parse(v, value, false); err != nil {
+			if err := parse(v, value, opts, false); err != nil {
 				return fmt.Errorf("failed to parse value at key: %s: %w", key, err)
 			}
 
@@ -139,3 +419,145 @@ func parse(v reflect.Value, text string, topLevel bool) error {
 
 	return nil
 }
+
+// autoDecodeBase64 returns the decoded bytes of data if it is standard base64, and data itself otherwise.
+// Since plain text such as words and paths is often valid base64 as well, data is only decoded when it is unambiguous:
+// when it is padded, or when it does not itself look like plain text and either decodes to printable text
+// or is long enough to be an encoded key, such as the 32 characters of a 24 byte key.
+func autoDecodeBase64(data []byte) []byte {
+	text := strings.TrimSpace(string(data))
+	if text == "" || len(text)%4 != 0 {
+		return data
+	}
+
+	decoded, err := base64.StdEncoding.Strict().DecodeString(text)
+	if err != nil {
+		return data
+	}
+
+	if strings.HasSuffix(text, "=") || !isPlainText(text) && (isPrintable(decoded) || len(text) >= minEncodedKeyLength) {
+		return decoded
+	}
+	return data
+}
+
+// minEncodedKeyLength is the length from which unpadded base64 text is decoded even if the result is binary.
+const minEncodedKeyLength = 32
+
+// isPlainText reports whether text only uses the characters of the base64 alphabet that are common in plain values:
+// lower case letters, digits and slashes, as in words and paths. Encoded data almost always mixes in upper case letters.
+func isPlainText(text string) bool {
+	for _, r := range text {
+		if !('a' <= r && r <= 'z' || '0' <= r && r <= '9' || r == '/') {
+			return false
+		}
+	}
+	return true
+}
+
+func isPrintable(data []byte) bool {
+	if !utf8.Valid(data) {
+		return false
+	}
+	for _, r := range string(data) {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// stripThousandsSeparator removes the configured thousands separator from numeric text.
+// It is only applied to scalar values, as the default separator of slices and maps is also a comma.
+func stripThousandsSeparator(text string, opts flagOptions) string {
+	if opts.thousandsSeparator == 0 {
+		return text
+	}
+	return strings.ReplaceAll(text, string(opts.thousandsSeparator), "")
+}
+
+// listSeparator returns the separator of slice elements and map entries, a comma unless the Separator option is set.
+func (opts flagOptions) listSeparator() string {
+	if opts.separator != "" {
+		return opts.separator
+	}
+	return ","
+}
+
+// cutSeparatorPrefix extracts a separator declared as @<sep> at the start of text.
+// If text does not declare a separator the fallback is returned and text is left as is.
+func cutSeparatorPrefix(text, fallback string) (separator, rest string) {
+	if !strings.HasPrefix(text, "@") {
+		return fallback, text
+	}
+	r, size := utf8.DecodeRuneInString(text[1:])
+	if r == utf8.RuneError {
+		return fallback, text
+	}
+	return string(r), text[1+size:]
+}
+
+var extendedDurationUnits = regexp.MustCompile(`([0-9]*\.?[0-9]+)([dw])`)
+
+// expandDuration rewrites day and week units into hours so that the result can be handled by time.ParseDuration.
+// For example 1d12h becomes 24h12h which time.ParseDuration sums to 36h.
+func expandDuration(text string) string {
+	return extendedDurationUnits.ReplaceAllStringFunc(text, func(match string) string {
+		groups := extendedDurationUnits.FindStringSubmatch(match)
+		n, err := strconv.ParseFloat(groups[1], 64)
+		if err != nil {
+			return match
+		}
+		hours := 24.0
+		if groups[2] == "w" {
+			hours *= 7
+		}
+		return strconv.FormatFloat(n*hours, 'f', -1, 64) + "h"
+	})
+}
+
+// format renders value as text that parse would read back into an equal value.
+func format(value any) string {
+	if value == nil {
+		return ""
+	}
+
+	if marshaler, ok := value.(encoding.TextMarshaler); ok {
+		if text, err := marshaler.MarshalText(); err == nil {
+			return string(text)
+		}
+	}
+
+	if stringer, ok := value.(fmt.Stringer); ok {
+		return stringer.String()
+	}
+
+	v := reflect.ValueOf(value)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+
+	switch v.Kind() {
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			return string(v.Bytes())
+		}
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = format(v.Index(i).Interface())
+		}
+		return strings.Join(items, ",")
+	case reflect.Map:
+		items := make([]string, 0, v.Len())
+		for iter := v.MapRange(); iter.Next(); {
+			items = append(items, format(iter.Key().Interface())+"="+format(iter.Value().Interface()))
+		}
+		sort.Strings(items)
+		return strings.Join(items, ",")
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
@@ -0,0 +1,3 @@
+// Package winreg provides a lookup backed by the values of a Windows registry key.
+// It is only available on Windows.
+package winreg
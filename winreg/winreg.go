@@ -0,0 +1,77 @@
+//go:build windows
+
+package winreg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/davidmdm/env"
+	"golang.org/x/sys/windows/registry"
+)
+
+// Lookup returns a lookup over the values of the registry key at path under root, for example:
+//
+//	winreg.Lookup(registry.LOCAL_MACHINE, `SOFTWARE\Acme\Service`)
+//
+// String values are used as is, with environment references of expandable strings expanded. Integer values are formatted
+// in decimal and multi-string values are joined by commas, so that they parse as slices. Other values, such as binary data,
+// are skipped. Values are read once when Lookup is called.
+func Lookup(root registry.Key, path string) (env.LookupFunc, error) {
+	key, err := registry.OpenKey(root, path, registry.QUERY_VALUE)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open registry key %s: %w", path, err)
+	}
+	defer key.Close()
+
+	names, err := key.ReadValueNames(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read registry key %s: %w", path, err)
+	}
+
+	vars := make(map[string]string, len(names))
+	for _, name := range names {
+		value, ok, err := readValue(key, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read registry value %s: %w", name, err)
+		}
+		if ok {
+			vars[name] = value
+		}
+	}
+
+	return func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	}, nil
+}
+
+// readValue returns the value called name as a string, reporting false for values of types that are not supported.
+func readValue(key registry.Key, name string) (string, bool, error) {
+	_, valtype, err := key.GetValue(name, nil)
+	if err != nil {
+		return "", false, err
+	}
+
+	switch valtype {
+	case registry.SZ:
+		value, _, err := key.GetStringValue(name)
+		return value, err == nil, err
+	case registry.EXPAND_SZ:
+		value, _, err := key.GetStringValue(name)
+		if err != nil {
+			return "", false, err
+		}
+		value, err = registry.ExpandString(value)
+		return value, err == nil, err
+	case registry.DWORD, registry.QWORD:
+		value, _, err := key.GetIntegerValue(name)
+		return strconv.FormatUint(value, 10), err == nil, err
+	case registry.MULTI_SZ:
+		values, _, err := key.GetStringsValue(name)
+		return strings.Join(values, ","), err == nil, err
+	default:
+		return "", false, nil
+	}
+}
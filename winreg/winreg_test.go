@@ -0,0 +1,59 @@
+//go:build windows
+
+package winreg_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/davidmdm/env"
+	"github.com/davidmdm/env/winreg"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/windows/registry"
+)
+
+func TestLookup(t *testing.T) {
+	path := fmt.Sprintf(`Software\davidmdm-env-test-%d`, time.Now().UnixNano())
+
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, path, registry.ALL_ACCESS)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		key.Close()
+		registry.DeleteKey(registry.CURRENT_USER, path)
+	})
+
+	require.NoError(t, key.SetStringValue("HOST", "localhost"))
+	require.NoError(t, key.SetDWordValue("PORT", 8080))
+	require.NoError(t, key.SetQWordValue("MAX_BYTES", 1<<40))
+	require.NoError(t, key.SetStringsValue("TAGS", []string{"a", "b"}))
+	require.NoError(t, key.SetBinaryValue("BLOB", []byte{1, 2, 3}))
+
+	lookup, err := winreg.Lookup(registry.CURRENT_USER, path)
+	require.NoError(t, err)
+
+	_, ok := lookup("BLOB")
+	require.False(t, ok)
+
+	var (
+		host     string
+		port     int
+		maxBytes int64
+		tags     []string
+	)
+
+	environment := env.MakeEnvSetIsolated(lookup)
+	env.FlagVar(environment, &host, "HOST")
+	env.FlagVar(environment, &port, "PORT")
+	env.FlagVar(environment, &maxBytes, "MAX_BYTES")
+	env.FlagVar(environment, &tags, "TAGS")
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, "localhost", host)
+	require.Equal(t, 8080, port)
+	require.Equal(t, int64(1<<40), maxBytes)
+	require.Equal(t, []string{"a", "b"}, tags)
+
+	_, err = winreg.Lookup(registry.CURRENT_USER, path+`\missing`)
+	require.Error(t, err)
+}
@@ -0,0 +1,86 @@
+package env
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// VarWriter registers a variable naming where output is written, and sets p accordingly: stdout and stderr are the streams
+// of the process, and any other value is the path of a file opened for appending, created if needed.
+// Files are only opened by Parse and stay open until EnvSet.Close is called. Parsing again reuses the file if the path did not change,
+// and closes it otherwise. If the variable is not set, p keeps its current value.
+func VarWriter(envset EnvSet, p *io.Writer, name string) {
+	var (
+		output string
+		opened = &openedFile{}
+	)
+
+	FlagVar(envset, &output, name)
+	*envset.closers = append(*envset.closers, opened)
+
+	name = envset.prefix + name
+
+	*envset.finalizers = append(*envset.finalizers, func() error {
+		switch output {
+		case "":
+			return nil
+		case "stdout", "stderr":
+			if err := opened.Close(); err != nil {
+				return fmt.Errorf("failed to close previous output of %s: %w", name, err)
+			}
+			if output == "stdout" {
+				*p = os.Stdout
+			} else {
+				*p = os.Stderr
+			}
+			return nil
+		}
+
+		if opened.file != nil && opened.path == output {
+			*p = opened.file
+			return nil
+		}
+
+		file, err := os.OpenFile(output, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+		if err != nil {
+			return fmt.Errorf("failed to open %s: %w", name, err)
+		}
+		if err := opened.Close(); err != nil {
+			file.Close()
+			return fmt.Errorf("failed to close previous output of %s: %w", name, err)
+		}
+
+		opened.path, opened.file = output, file
+		*p = file
+		return nil
+	})
+}
+
+// openedFile is the file currently opened for a VarWriter variable.
+type openedFile struct {
+	path string
+	file *os.File
+}
+
+func (f *openedFile) Close() error {
+	if f.file == nil {
+		return nil
+	}
+	err := f.file.Close()
+	f.path, f.file = "", nil
+	return err
+}
+
+// Close closes the files opened while parsing the EnvSet, such as those of VarWriter variables.
+// Parsing again after Close opens them again.
+func (env EnvSet) Close() error {
+	var errs []error
+	for _, closer := range *env.closers {
+		if err := closer.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package env_test
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/davidmdm/env"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVarWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+
+	vars := map[string]string{"LOG_OUTPUT": "stderr", "AUDIT_OUTPUT": path}
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	})
+
+	var logOutput, auditOutput, traceOutput io.Writer = nil, nil, os.Stdout
+
+	env.VarWriter(environment, &logOutput, "LOG_OUTPUT")
+	env.VarWriter(environment, &auditOutput, "AUDIT_OUTPUT")
+	env.VarWriter(environment, &traceOutput, "TRACE_OUTPUT")
+
+	require.NoError(t, environment.Validate())
+	require.Nil(t, logOutput)
+	require.NoFileExists(t, path)
+
+	require.NoError(t, environment.Parse())
+	require.Equal(t, os.Stderr, logOutput)
+	require.Equal(t, os.Stdout, traceOutput)
+
+	_, err := io.WriteString(auditOutput, "hello\n")
+	require.NoError(t, err)
+	require.NoError(t, environment.Close())
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "hello\n", string(data))
+
+	vars["AUDIT_OUTPUT"] = filepath.Join(path, "missing", "app.log")
+	require.ErrorContains(t, environment.Parse(), "failed to open AUDIT_OUTPUT: open "+vars["AUDIT_OUTPUT"])
+}
+
+func TestVarWriterParseAgain(t *testing.T) {
+	dir := t.TempDir()
+	first, second := filepath.Join(dir, "first.log"), filepath.Join(dir, "second.log")
+
+	vars := map[string]string{"OUTPUT": first}
+
+	environment := env.MakeEnvSet(func(name string) (string, bool) {
+		value, ok := vars[name]
+		return value, ok
+	})
+
+	var output io.Writer
+	env.VarWriter(environment, &output, "OUTPUT")
+
+	require.NoError(t, environment.Parse())
+	firstFile := output.(*os.File)
+
+	require.NoError(t, environment.Parse())
+	require.Same(t, firstFile, output)
+
+	vars["OUTPUT"] = second
+	require.NoError(t, environment.Parse())
+	secondFile := output.(*os.File)
+	require.Equal(t, second, secondFile.Name())
+	require.ErrorIs(t, firstFile.Close(), os.ErrClosed)
+
+	vars["OUTPUT"] = "stdout"
+	require.NoError(t, environment.Parse())
+	require.Equal(t, os.Stdout, output)
+	require.ErrorIs(t, secondFile.Close(), os.ErrClosed)
+
+	vars["OUTPUT"] = first
+	require.NoError(t, environment.Parse())
+	require.NoError(t, environment.Close())
+	require.ErrorIs(t, output.(*os.File).Close(), os.ErrClosed)
+}